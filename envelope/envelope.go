@@ -0,0 +1,143 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package envelope implements envelope encryption on top of secretbox.
+//
+// Every message is encrypted with its own random data encryption key
+// (DEK). The DEK itself is encrypted (wrapped) with a long-term key
+// encryption key (KEK). An envelope has the form:
+//
+//	version (1 byte) || wrapped DEK (68 bytes) || payload
+//
+// The wrapped DEK and the payload are secretbox ciphertexts. The version
+// is authenticated as part of both. Since the payload does not depend on
+// the KEK, Rewrap can move an envelope to a new KEK without touching the
+// payload.
+package envelope
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"io"
+
+	"github.com/aead/hydrogen/secretbox"
+)
+
+const (
+	// Version is the version of the envelope format.
+	Version = 1
+	// KeySize is the size of the key encryption key in bytes.
+	KeySize = secretbox.KeySize
+	// WrappedKeySize is the size of the wrapped data encryption key in bytes.
+	WrappedKeySize = secretbox.KeySize + secretbox.HeaderSize
+	// Overhead is the overhead of an envelope in bytes.
+	Overhead = 1 + WrappedKeySize + secretbox.HeaderSize
+)
+
+// The msg ids of the wrapped DEK and the payload.
+const (
+	idWrap uint64 = iota
+	idPayload
+)
+
+var (
+	errFormat  = errors.New("hydrogen/envelope: invalid envelope")
+	errVersion = errors.New("hydrogen/envelope: unsupported envelope version")
+	errUnwrap  = errors.New("hydrogen/envelope: failed to unwrap data key")
+)
+
+// Seal encrypts msg with a new random data key, wraps the data key
+// with the kek and returns the envelope. The reader should return random
+// data or can be nil - than the PRNG of the system will be used.
+// Seal returns a non-nil error if the reader fails. The context must be
+// 8 and the kek 32 bytes long, otherwise this function panics.
+func Seal(rand io.Reader, msg, context, kek []byte) ([]byte, error) {
+	if rand == nil {
+		rand = crand.Reader
+	}
+	config := &secretbox.Config{Rand: rand}
+
+	dek, err := secretbox.GenerateKey(rand)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+
+	envelope := make([]byte, 1+WrappedKeySize, Overhead+len(msg))
+	envelope[0] = Version
+	if err = config.EncryptAD(envelope[1:1+WrappedKeySize], dek, envelope[:1], idWrap, context, kek); err != nil {
+		return nil, err
+	}
+
+	payload := envelope[1+WrappedKeySize : Overhead+len(msg)]
+	if err = config.EncryptAD(payload, msg, envelope[:1], idPayload, context, dek); err != nil {
+		return nil, err
+	}
+	return envelope[:Overhead+len(msg)], nil
+}
+
+// Open unwraps the data key of the envelope with the kek and returns
+// the decrypted message. Open returns a non-nil error if the envelope
+// could not be decrypted. The context must be 8 and the kek 32 bytes
+// long, otherwise this function panics.
+func Open(envelope, context, kek []byte) ([]byte, error) {
+	dek, err := unwrap(envelope, context, kek)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+
+	payload := envelope[1+WrappedKeySize:]
+	msg := make([]byte, len(payload)-secretbox.HeaderSize)
+	if err = secretbox.DecryptAD(msg, payload, envelope[:1], idPayload, context, dek); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// Rewrap unwraps the data key of the envelope with the oldKEK, wraps it with
+// the newKEK and returns the new envelope. The payload is copied unchanged.
+// The reader should return random data or can be nil - than the PRNG of the
+// system will be used. Rewrap returns a non-nil error if the data key could
+// not be unwrapped or the reader fails. The context must be 8 and both keys
+// 32 bytes long, otherwise this function panics.
+func Rewrap(rand io.Reader, envelope, context, oldKEK, newKEK []byte) ([]byte, error) {
+	if rand == nil {
+		rand = crand.Reader
+	}
+	dek, err := unwrap(envelope, context, oldKEK)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe(dek)
+
+	config := &secretbox.Config{Rand: rand}
+	rewrapped := make([]byte, len(envelope))
+	rewrapped[0] = envelope[0]
+	if err = config.EncryptAD(rewrapped[1:1+WrappedKeySize], dek, rewrapped[:1], idWrap, context, newKEK); err != nil {
+		return nil, err
+	}
+	copy(rewrapped[1+WrappedKeySize:], envelope[1+WrappedKeySize:])
+	return rewrapped, nil
+}
+
+func unwrap(envelope, context, kek []byte) ([]byte, error) {
+	if len(envelope) < Overhead {
+		return nil, errFormat
+	}
+	if envelope[0] != Version {
+		return nil, errVersion
+	}
+	dek := make([]byte, secretbox.KeySize)
+	if err := secretbox.DecryptAD(dek, envelope[1:1+WrappedKeySize], envelope[:1], idWrap, context, kek); err != nil {
+		return nil, errUnwrap
+	}
+	return dek, nil
+}
+
+func wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
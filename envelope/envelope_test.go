@@ -0,0 +1,66 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package envelope
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEnvelope(t *testing.T) {
+	context := []byte("libtests")
+	kek := bytes.Repeat([]byte{1}, KeySize)
+	newKEK := bytes.Repeat([]byte{2}, KeySize)
+
+	for _, size := range []int{0, 1, 64, 1000} {
+		msg := bytes.Repeat([]byte{byte(size)}, size)
+		envelope, err := Seal(nil, msg, context, kek)
+		if err != nil {
+			t.Fatalf("%d: Seal failed: %v", size, err)
+		}
+		if len(envelope) != len(msg)+Overhead {
+			t.Fatalf("%d: got envelope of %d bytes - want %d", size, len(envelope), len(msg)+Overhead)
+		}
+		if p, err := Open(envelope, context, kek); err != nil || !bytes.Equal(p, msg) {
+			t.Fatalf("%d: Open failed: %v", size, err)
+		}
+		if _, err = Open(envelope, context, newKEK); err == nil {
+			t.Fatalf("%d: Open accepted wrong kek", size)
+		}
+
+		rewrapped, err := Rewrap(nil, envelope, context, kek, newKEK)
+		if err != nil {
+			t.Fatalf("%d: Rewrap failed: %v", size, err)
+		}
+		if !bytes.Equal(rewrapped[1+WrappedKeySize:], envelope[1+WrappedKeySize:]) {
+			t.Fatalf("%d: Rewrap modified the payload", size)
+		}
+		if p, err := Open(rewrapped, context, newKEK); err != nil || !bytes.Equal(p, msg) {
+			t.Fatalf("%d: Open of rewrapped envelope failed: %v", size, err)
+		}
+		if _, err = Open(rewrapped, context, kek); err == nil {
+			t.Fatalf("%d: Open accepted old kek", size)
+		}
+	}
+}
+
+func TestEnvelopeTampering(t *testing.T) {
+	context := []byte("libtests")
+	kek := make([]byte, KeySize)
+	envelope, err := Seal(nil, []byte("Hello World"), context, kek)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = Open(envelope[:Overhead-1], context, kek); err == nil {
+		t.Fatal("Open accepted truncated envelope")
+	}
+	for i := range envelope {
+		envelope[i] ^= 1
+		if _, err = Open(envelope, context, kek); err == nil {
+			t.Fatalf("Open accepted envelope modified at byte %d", i)
+		}
+		envelope[i] ^= 1
+	}
+}
@@ -0,0 +1,218 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package hash provides a general-purpose hash function with
+// a variable output length.
+//
+// Therefore this package uses BLAKE2Xs - the extendable output
+// function built on BLAKE2s. The 8 byte context is used as the
+// BLAKE2s personalization and the optional key turns the hash
+// function into a PRF / MAC.
+package hash
+
+import (
+	"errors"
+	"hash"
+	"io"
+	"strconv"
+
+	"github.com/aead/hydrogen/internal/blake2s"
+)
+
+const (
+	// Size is the default size of a checksum in bytes.
+	Size = 32
+	// MinSize is the minimum size of a checksum in bytes.
+	MinSize = 16
+	// MaxSize is the maximum size of a checksum in bytes.
+	// BLAKE2X reserves 65535 for OutputLengthUnknown.
+	MaxSize = 65534
+	// KeySize is the size of the (optional) key in bytes.
+	KeySize = 32
+	// BlockSize is the blocksize of BLAKE2s in bytes.
+	BlockSize = blake2s.BlockSize
+
+	// OutputLengthUnknown can be passed to NewXOF if the
+	// output length is not known in advance. In this case
+	// up to 128 GB can be read from the XOF.
+	OutputLengthUnknown = 65535
+)
+
+// XOF is an extendable output function. Data can be written
+// to a XOF until the first call of Read. Further calls of Write
+// after the first Read panic.
+type XOF interface {
+	io.Writer
+
+	// Read reads more output from the XOF. If the output length
+	// is known in advance, it returns io.EOF after reading the
+	// specified number of bytes.
+	io.Reader
+
+	// Reset resets the XOF to its initial state.
+	Reset()
+}
+
+// Sum computes the checksum of msg using the given context and key and
+// writes the result to out. The context must be 8 bytes long and the
+// key must be either empty or 32 bytes long. The size of out must be
+// between MinSize and MaxSize. Otherwise this function panics.
+func Sum(out, msg, context, key []byte) {
+	checkSize(len(out))
+	d := newDigest(len(out), context, key)
+	d.Write(msg)
+	d.Read(out)
+}
+
+// New returns a new hash.Hash computing a checksum of the given size
+// with the given context and key. The context must be 8 bytes long
+// and the key must be either empty or 32 bytes long. The size must be
+// between MinSize and MaxSize. Otherwise this function panics.
+func New(size int, context, key []byte) hash.Hash {
+	checkSize(size)
+	return newDigest(size, context, key)
+}
+
+// NewXOF returns a new XOF producing size bytes with the given context
+// and key. The context must be 8 bytes long and the key must be either
+// empty or 32 bytes long. The size must be between 1 and MaxSize or
+// OutputLengthUnknown. Otherwise this function panics.
+func NewXOF(size int, context, key []byte) XOF {
+	if (size < 1 || size > MaxSize) && size != OutputLengthUnknown {
+		panic("hydrogen/hash: invalid output size " + strconv.Itoa(size))
+	}
+	return newDigest(size, context, key)
+}
+
+func checkSize(size int) {
+	if size < MinSize || size > MaxSize {
+		panic("hydrogen/hash: invalid output size " + strconv.Itoa(size))
+	}
+}
+
+type digest struct {
+	root    *blake2s.Digest
+	size    int
+	context [8]byte
+
+	h0        [blake2s.Size]byte
+	block     [blake2s.Size]byte
+	off       int
+	node      uint32
+	remaining uint64
+	reading   bool
+}
+
+func newDigest(size int, context, key []byte) *digest {
+	if k := len(key); k != 0 && k != KeySize {
+		panic("hydrogen/hash: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/hash: invalid context size " + strconv.Itoa(c))
+	}
+	d := &digest{size: size}
+	copy(d.context[:], context)
+
+	// H0 = BLAKE2s(msg, key) with fanout = depth = 1 and xof_length = size
+	p := &blake2s.Params{
+		DigestSize: blake2s.Size,
+		Fanout:     1,
+		Depth:      1,
+		XOFLength:  uint16(size),
+		Personal:   d.context,
+	}
+	d.root = blake2s.New(p, key)
+	d.Reset()
+	return d
+}
+
+func (d *digest) Size() int { return d.size }
+
+func (d *digest) BlockSize() int { return BlockSize }
+
+func (d *digest) Reset() {
+	d.root.Reset()
+	d.resetOutput()
+}
+
+func (d *digest) resetOutput() {
+	d.off = len(d.block)
+	d.node = 0
+	d.reading = false
+	d.remaining = uint64(d.size)
+	if d.size == OutputLengthUnknown {
+		d.remaining = blake2s.Size << 32
+	}
+}
+
+func (d *digest) Write(p []byte) (n int, err error) {
+	if d.reading {
+		panic("hydrogen/hash: write after read")
+	}
+	return d.root.Write(p)
+}
+
+func (d *digest) Sum(sum []byte) []byte {
+	out := make([]byte, d.size)
+	x := &digest{root: d.root, size: d.size, context: d.context}
+	x.resetOutput()
+	x.Read(out)
+	return append(sum, out...)
+}
+
+var errTooLong = errors.New("hydrogen/hash: XOF output length exceeded")
+
+func (d *digest) Read(p []byte) (n int, err error) {
+	if !d.reading {
+		d.root.Sum(d.h0[:0])
+		d.reading = true
+	}
+	if d.remaining == 0 {
+		if d.size == OutputLengthUnknown {
+			return 0, errTooLong
+		}
+		return 0, io.EOF
+	}
+	if uint64(len(p)) > d.remaining {
+		p = p[:d.remaining]
+	}
+	for len(p) > 0 {
+		if d.off == len(d.block) {
+			d.expand()
+		}
+		c := copy(p, d.block[d.off:])
+		d.off += c
+		n += c
+		p = p[c:]
+		d.remaining -= uint64(c)
+	}
+	return
+}
+
+// expand computes the next output block:
+// B_i = BLAKE2s(H0) with leaf_length = inner_length = 32, node_offset = i
+// and xof_length = size. The last block is truncated to the remaining size.
+func (d *digest) expand() {
+	size := uint64(blake2s.Size)
+	if d.size != OutputLengthUnknown && d.remaining < size {
+		size = d.remaining
+	}
+	p := &blake2s.Params{
+		DigestSize:  byte(size),
+		LeafLength:  blake2s.Size,
+		NodeOffset:  d.node,
+		XOFLength:   uint16(d.size),
+		InnerLength: blake2s.Size,
+		Personal:    d.context,
+	}
+	b := blake2s.New(p, nil)
+	b.Write(d.h0[:])
+	b.Sum(d.block[:0])
+
+	d.off = len(d.block) - int(size)
+	if d.off > 0 {
+		copy(d.block[d.off:], d.block[:size])
+	}
+	d.node++
+}
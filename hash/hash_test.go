@@ -0,0 +1,161 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for i, v := range vectors {
+		msg := make([]byte, v.length)
+		for j := range msg {
+			msg[j] = byte(j % 251)
+		}
+		k := key
+		if !v.keyed {
+			k = nil
+		}
+		want := fromHex(v.sum)
+
+		out := make([]byte, v.size)
+		Sum(out, msg, context, k)
+		if !bytes.Equal(out, want) {
+			t.Errorf("%d (sum): got:  %s - want: %s", i, hex.EncodeToString(out), v.sum)
+		}
+
+		h := New(v.size, context, k)
+		for j := range msg {
+			h.Write(msg[j : j+1])
+		}
+		if sum := h.Sum(nil); !bytes.Equal(sum, want) {
+			t.Errorf("%d (hash): got:  %s - want: %s", i, hex.EncodeToString(sum), v.sum)
+		}
+
+		x := NewXOF(v.size, context, k)
+		x.Write(msg)
+		out, err := ioutil.ReadAll(x)
+		if err != nil {
+			t.Fatalf("%d (xof): failed to read: %v", i, err)
+		}
+		if !bytes.Equal(out, want) {
+			t.Errorf("%d (xof): got:  %s - want: %s", i, hex.EncodeToString(out), v.sum)
+		}
+	}
+}
+
+func TestXOFUnknownLength(t *testing.T) {
+	const want = "f721ad4b874a7cb797ef8c0beff150a505bcd2034d0b96a24569ec385086e69733ea443d52d65a7365a54bceae9334cff0a34b2de840239d979cfd08bb2aeba7b0b54252270e4fc814a2a0cb8a1eb6a78ad58cbd4b72bdfc25c203c4bddebfe00a7ebc5c"
+
+	x := NewXOF(OutputLengthUnknown, []byte("libtests"), nil)
+	x.Write([]byte("abc"))
+	out := make([]byte, 100)
+	for i := 0; i < len(out); i += 7 {
+		j := i + 7
+		if j > len(out) {
+			j = len(out)
+		}
+		if _, err := io.ReadFull(x, out[i:j]); err != nil {
+			t.Fatalf("Failed to read from XOF: %v", err)
+		}
+	}
+	if !bytes.Equal(out, fromHex(want)) {
+		t.Fatalf("got:  %s - want: %s", hex.EncodeToString(out), want)
+	}
+
+	x.Reset()
+	x.Write([]byte("abc"))
+	if _, err := io.ReadFull(x, out); err != nil || !bytes.Equal(out, fromHex(want)) {
+		t.Fatalf("Reset did not reset the XOF")
+	}
+}
+
+func TestMaxSize(t *testing.T) {
+	context := []byte("libtests")
+	msg := []byte("abc")
+
+	x := NewXOF(OutputLengthUnknown, context, nil)
+	x.Write(msg)
+	unknown := make([]byte, MaxSize)
+	if _, err := io.ReadFull(x, unknown); err != nil {
+		t.Fatalf("Failed to read from XOF: %v", err)
+	}
+
+	sum := make([]byte, MaxSize)
+	Sum(sum, msg, context, nil)
+	if bytes.Equal(sum, unknown) {
+		t.Fatal("checksum of MaxSize bytes equals output of unknown length")
+	}
+
+	h := New(MaxSize, context, nil)
+	h.Write(msg)
+	if !bytes.Equal(h.Sum(nil), sum) {
+		t.Fatal("New and Sum differ for MaxSize")
+	}
+
+	for _, size := range []int{0, MaxSize + 2, OutputLengthUnknown} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Sum accepted output size %d", size)
+				}
+			}()
+			Sum(make([]byte, size), msg, context, nil)
+		}()
+	}
+	for _, size := range []int{0, OutputLengthUnknown + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("NewXOF accepted output size %d", size)
+				}
+			}()
+			NewXOF(size, context, nil)
+		}()
+	}
+}
+
+var vectors = []struct {
+	length, size int
+	keyed        bool
+	sum          string
+}{
+	{0, 16, false, "14e20844639ac2aa2cb2788944625c62"},
+	{3, 32, false, "f61fe09f97ad9a8798993dd3edee3d5dea6bd970d635218269478dfe431f5290"},
+	{64, 32, true, "59de3cf963caf9fca75f9b00d4daf3aef1f7bae337183aa3f4f6ea8c377a25b7"},
+	{65, 33, true, "47063007086c638ff6694ea9e67e23e7a53dca18ef4c17a7d09a24ab22bd9c0b33"},
+	{200, 100, false, "94f1888b79f1f983a71df371fd19b874669466f736dd732692c6d7e6dad9ac92daf3514fcd3f41d0606856522f99140a94ff89cd60f0fd8547dfae5a5ffae8381a1c9036c12275843db45c5b069021644249aa570a5d3b18589a537df0862777fd09753e"},
+	{1000, 16, true, "b942c64a4837ad1c975d7ba34748310a"},
+	{7, 257, true, "8508c9ee1def06c7a96a0f14eb4437fcab6e5e4243097c072d386b842f0760f03e1c3103adad882afa395de02df3f7ca746843dc5e2a4f0a49f9ec612d18dc0bdd865611ee2ef348ceb791874a3b6ce6e229f83da315e5dc8bf34546c74861fdbf1eaa095a74af7838c4ad69367e59168080a6fb9aece7fc82b7da788c295749829dc16dfb34455466af6cd3c04b4d72a5b915e5e0234b2d5c92007922470e8123fa3f6c4caf52213c5c443074301f2b641d460f38e7e406a2943814859ec16425baaff02ee23153880ddbbd9c97c00ce47cd77fc568b32259bedb4b60f4ae72b8219aecc14a9d56ae0865cac2de25f11d39259ba8f939a6a81d4bdc2a93d67c35"},
+}
+
+func benchSum(size int, b *testing.B) {
+	context := []byte("runbench")
+	msg := make([]byte, size)
+	out := make([]byte, Size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Sum(out, msg, context, nil)
+	}
+}
+
+func BenchmarkSum64(b *testing.B) { benchSum(64, b) }
+func BenchmarkSum1K(b *testing.B) { benchSum(1024, b) }
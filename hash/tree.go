@@ -0,0 +1,271 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package hash
+
+import (
+	"errors"
+	"io"
+	"runtime"
+	"strconv"
+	"sync"
+
+	"github.com/aead/hydrogen/internal/blake2s"
+	"github.com/aead/hydrogen/subtle"
+)
+
+// Tree computes checksums of large inputs using the BLAKE2s tree
+// hashing mode. The input is split into chunks which are hashed
+// in parallel. The chunk checksums are combined into a binary tree,
+// such that the checksum of the root node depends on the entire
+// input but not on the number of workers.
+//
+// Every chunk can be verified against the root checksum using a Proof
+// without hashing the rest of the input.
+//
+// The tree is left-balanced: The left subtree of a node with n > 1
+// chunks contains the largest power of two < n chunks. Each node is
+// hashed with fanout = 2, unlimited depth, inner_length = 32 and
+// leaf_length = chunk size. The node_offset is the index of the node
+// within its level and the node_depth is 0 for chunks and the height
+// of the subtree for all other nodes. The root node is finalized with
+// the last node flag.
+type Tree struct {
+	chunkSize int
+	workers   int
+	context   [8]byte
+	key       []byte
+}
+
+// Proof proves that a chunk is part of the input of a Tree checksum.
+type Proof struct {
+	Index    uint64       // The index of the chunk
+	Chunks   uint64       // The total number of chunks
+	Siblings [][Size]byte // The sibling checksums from the root to the chunk
+}
+
+// NewTree returns a new Tree splitting its input into chunks of the given
+// size and hashing them using up to the given number of goroutines. If
+// workers <= 0 the number of CPUs is used. The context must be 8 bytes long,
+// the key must be either empty or 32 bytes long and the chunk size must fit
+// into 32 bits. Otherwise this function panics.
+func NewTree(chunkSize, workers int, context, key []byte) *Tree {
+	if chunkSize <= 0 || uint64(chunkSize) > 1<<32-1 {
+		panic("hydrogen/hash: invalid chunk size " + strconv.Itoa(chunkSize))
+	}
+	if k := len(key); k != 0 && k != KeySize {
+		panic("hydrogen/hash: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/hash: invalid context size " + strconv.Itoa(c))
+	}
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	t := &Tree{
+		chunkSize: chunkSize,
+		workers:   workers,
+		key:       make([]byte, len(key)),
+	}
+	copy(t.context[:], context)
+	copy(t.key, key)
+	return t
+}
+
+// Sum returns the root checksum of the first size bytes of r.
+// It returns a non-nil error if reading from r fails.
+func (t *Tree) Sum(r io.ReaderAt, size int64) (root [Size]byte, err error) {
+	leaves, err := t.leaves(r, size)
+	if err != nil {
+		return
+	}
+	root = t.root(leaves, 0, uint64(len(leaves)), nil)
+	return
+}
+
+// Prove returns a Proof for the chunk with the given index of the
+// first size bytes of r. It returns a non-nil error if reading from
+// r fails or the index is out of range.
+func (t *Tree) Prove(r io.ReaderAt, size int64, index uint64) (*Proof, error) {
+	leaves, err := t.leaves(r, size)
+	if err != nil {
+		return nil, err
+	}
+	if index >= uint64(len(leaves)) {
+		return nil, errIndexOutOfRange
+	}
+	p := &Proof{Index: index, Chunks: uint64(len(leaves))}
+	t.root(leaves, 0, p.Chunks, p)
+	return p, nil
+}
+
+// Verify returns true if and only if the given chunk and proof match
+// the root checksum.
+func (t *Tree) Verify(root [Size]byte, chunk []byte, proof *Proof) bool {
+	if proof.Chunks == 0 || proof.Index >= proof.Chunks {
+		return false
+	}
+	if len(chunk) > t.chunkSize || (proof.Index < proof.Chunks-1 && len(chunk) != t.chunkSize) {
+		return false
+	}
+
+	type node struct {
+		lo, mid uint64
+		height  byte
+	}
+	var path []node
+	lo, hi := uint64(0), proof.Chunks
+	for hi-lo > 1 {
+		mid, height := split(lo, hi)
+		path = append(path, node{lo, mid, height})
+		if proof.Index < mid {
+			hi = mid
+		} else {
+			lo = mid
+		}
+	}
+	if len(path) != len(proof.Siblings) {
+		return false
+	}
+
+	var sum [Size]byte
+	t.hashLeaf(&sum, chunk, proof.Index, proof.Chunks == 1)
+	for i := len(path) - 1; i >= 0; i-- {
+		n, sibling := path[i], proof.Siblings[i]
+		if proof.Index < n.mid {
+			t.hashParent(&sum, &sum, &sibling, n.lo>>n.height, n.height, i == 0)
+		} else {
+			t.hashParent(&sum, &sibling, &sum, n.lo>>n.height, n.height, i == 0)
+		}
+	}
+	return subtle.Equal(sum[:], root[:])
+}
+
+var errIndexOutOfRange = errors.New("hydrogen/hash: chunk index out of range")
+
+// root computes the checksum of the subtree containing the leaves
+// [lo, hi). If p is not nil, root appends the siblings of the path
+// to the chunk p.Index to p.Siblings.
+func (t *Tree) root(leaves [][Size]byte, lo, hi uint64, p *Proof) (sum [Size]byte) {
+	if hi-lo == 1 {
+		return leaves[lo]
+	}
+	mid, height := split(lo, hi)
+
+	var left, right [Size]byte
+	if p != nil && p.Index >= lo && p.Index < hi {
+		if p.Index < mid {
+			right = t.root(leaves, mid, hi, nil)
+			p.Siblings = append(p.Siblings, right)
+			left = t.root(leaves, lo, mid, p)
+		} else {
+			left = t.root(leaves, lo, mid, nil)
+			p.Siblings = append(p.Siblings, left)
+			right = t.root(leaves, mid, hi, p)
+		}
+	} else {
+		left = t.root(leaves, lo, mid, nil)
+		right = t.root(leaves, mid, hi, nil)
+	}
+	t.hashParent(&sum, &left, &right, lo>>height, height, lo == 0 && hi == uint64(len(leaves)))
+	return
+}
+
+// split returns the index of the first leaf of the right subtree
+// of the leaves [lo, hi) and the height of the subtree.
+func split(lo, hi uint64) (mid uint64, height byte) {
+	n := hi - lo
+	left := uint64(1)
+	for left<<1 < n {
+		left <<= 1
+		height++
+	}
+	return lo + left, height + 1
+}
+
+// leaves computes the checksums of all chunks of r in parallel.
+func (t *Tree) leaves(r io.ReaderAt, size int64) ([][Size]byte, error) {
+	if size < 0 {
+		panic("hydrogen/hash: invalid input size " + strconv.FormatInt(size, 10))
+	}
+	n := uint64(size+int64(t.chunkSize)-1) / uint64(t.chunkSize)
+	if n == 0 {
+		n = 1 // the empty input consists of one empty chunk
+	}
+	leaves := make([][Size]byte, n)
+
+	workers := t.workers
+	if uint64(workers) > n {
+		workers = int(n)
+	}
+	indices := make(chan uint64)
+	errs := make(chan error, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, t.chunkSize)
+			for i := range indices {
+				off := int64(i) * int64(t.chunkSize)
+				chunk := buf
+				if rem := size - off; rem < int64(len(chunk)) {
+					chunk = chunk[:rem]
+				}
+				if m, err := r.ReadAt(chunk, off); m != len(chunk) {
+					if err == nil {
+						err = io.ErrUnexpectedEOF
+					}
+					errs <- err
+					for range indices { // drain remaining work
+					}
+					return
+				}
+				t.hashLeaf(&leaves[i], chunk, i, n == 1)
+			}
+		}()
+	}
+	for i := uint64(0); i < n; i++ {
+		indices <- i
+	}
+	close(indices)
+	wg.Wait()
+
+	select {
+	case err := <-errs:
+		return nil, err
+	default:
+		return leaves, nil
+	}
+}
+
+func (t *Tree) node(offset uint64, height byte, isRoot bool) *blake2s.Digest {
+	p := &blake2s.Params{
+		DigestSize:  Size,
+		Fanout:      2,
+		Depth:       255,
+		LeafLength:  uint32(t.chunkSize),
+		NodeOffset:  uint32(offset),
+		XOFLength:   uint16(offset >> 32), // the upper 16 bits of the 48 bit node offset
+		NodeDepth:   height,
+		InnerLength: Size,
+		Personal:    t.context,
+	}
+	d := blake2s.New(p, t.key)
+	d.LastNode = isRoot
+	return d
+}
+
+func (t *Tree) hashLeaf(sum *[Size]byte, chunk []byte, index uint64, isRoot bool) {
+	d := t.node(index, 0, isRoot)
+	d.Write(chunk)
+	d.Sum(sum[:0])
+}
+
+func (t *Tree) hashParent(sum, left, right *[Size]byte, offset uint64, height byte, isRoot bool) {
+	d := t.node(offset, height, isRoot)
+	d.Write(left[:])
+	d.Write(right[:])
+	d.Sum(sum[:0])
+}
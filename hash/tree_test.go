@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package hash
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func treeInput(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i % 251)
+	}
+	return data
+}
+
+func TestTreeVectors(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for i, v := range treeVectors {
+		k := key
+		if !v.keyed {
+			k = nil
+		}
+		data := treeInput(v.size)
+		for _, workers := range []int{1, 2, 3, 16} {
+			root, err := NewTree(v.chunkSize, workers, context, k).Sum(bytes.NewReader(data), int64(len(data)))
+			if err != nil {
+				t.Fatalf("%d: failed to compute tree checksum: %v", i, err)
+			}
+			if !bytes.Equal(root[:], fromHex(v.root)) {
+				t.Errorf("%d (workers %d): got:  %s - want: %s", i, workers, hex.EncodeToString(root[:]), v.root)
+			}
+		}
+	}
+}
+
+func TestTreeProof(t *testing.T) {
+	const chunkSize = 64
+	context := []byte("libtests")
+	for _, size := range []int{0, 1, 64, 65, 200, 1000} {
+		data := treeInput(size)
+		tree := NewTree(chunkSize, 0, context, nil)
+		root, err := tree.Sum(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			t.Fatalf("size %d: failed to compute tree checksum: %v", size, err)
+		}
+
+		chunks := (size + chunkSize - 1) / chunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		for i := 0; i < chunks; i++ {
+			proof, err := tree.Prove(bytes.NewReader(data), int64(len(data)), uint64(i))
+			if err != nil {
+				t.Fatalf("size %d, chunk %d: failed to create proof: %v", size, i, err)
+			}
+			end := (i + 1) * chunkSize
+			if end > size {
+				end = size
+			}
+			chunk := append([]byte(nil), data[i*chunkSize:end]...)
+			if !tree.Verify(root, chunk, proof) {
+				t.Fatalf("size %d, chunk %d: Verify rejected valid proof", size, i)
+			}
+			if len(chunk) > 0 {
+				chunk[0]++
+				if tree.Verify(root, chunk, proof) {
+					t.Fatalf("size %d, chunk %d: Verify accepted modified chunk", size, i)
+				}
+				chunk[0]--
+			}
+			if len(proof.Siblings) > 0 {
+				proof.Siblings = proof.Siblings[1:]
+				if tree.Verify(root, chunk, proof) {
+					t.Fatalf("size %d, chunk %d: Verify accepted truncated proof", size, i)
+				}
+			}
+		}
+		if _, err = tree.Prove(bytes.NewReader(data), int64(len(data)), uint64(chunks)); err == nil {
+			t.Fatalf("size %d: Prove accepted out of range index", size)
+		}
+	}
+}
+
+func TestTreeShortInput(t *testing.T) {
+	data := treeInput(100)
+	if _, err := NewTree(64, 2, []byte("libtests"), nil).Sum(bytes.NewReader(data), 200); err == nil {
+		t.Fatal("Sum accepted input shorter than the given size")
+	}
+}
+
+var treeVectors = []struct {
+	size, chunkSize int
+	keyed           bool
+	root            string
+}{
+	{0, 64, false, "ab1899edad2044b5375a8c0eb99368a40c04917c8cbc1424452bfde3bf1a762f"},
+	{1, 64, false, "53904044b5a99673c5d6b21d6957c2f93697fa397aa854ce7166c6566765fa97"},
+	{64, 64, true, "5b677b88fece4e90137bbdad3214a5a9036639ff1b31eb873266de8825b01192"},
+	{65, 64, false, "afe7427c1e6ec173e880053bf8bf3fca8cc7e74f863249b7acb408407d6175b5"},
+	{1000, 64, true, "5209977ff2e57c804d97fc8b0376abe963691e4cad96db6704c60e8930f2fecf"},
+	{4096, 100, false, "cc0dd22a7bff5e1bc26227776af7d1fc7eaf237b2f3fcd4ec46cf22a64729ad3"},
+}
+
+func benchTree(workers int, b *testing.B) {
+	const size = 16 << 20
+	data := make([]byte, size)
+	tree := NewTree(64<<10, workers, []byte("runbench"), nil)
+
+	b.SetBytes(size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		tree.Sum(bytes.NewReader(data), size)
+	}
+}
+
+func BenchmarkTree1(b *testing.B) { benchTree(1, b) }
+func BenchmarkTree4(b *testing.B) { benchTree(4, b) }
@@ -0,0 +1,159 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package blake2s implements the BLAKE2s hash function with
+// support for the full BLAKE2s parameter block as specified
+// in the BLAKE2 paper and the BLAKE2X specification.
+package blake2s
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+const (
+	// BlockSize is the blocksize of BLAKE2s in bytes.
+	BlockSize = 64
+	// Size is the maximum digest size of BLAKE2s in bytes.
+	Size = 32
+	// KeySize is the maximum key size of BLAKE2s in bytes.
+	KeySize = 32
+)
+
+var iv = [8]uint32{
+	0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a,
+	0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19,
+}
+
+// Params is the BLAKE2s parameter block.
+type Params struct {
+	DigestSize  byte
+	Fanout      byte
+	Depth       byte
+	LeafLength  uint32
+	NodeOffset  uint32
+	XOFLength   uint16
+	NodeDepth   byte
+	InnerLength byte
+	Salt        [8]byte
+	Personal    [8]byte
+}
+
+// Digest computes a BLAKE2s checksum. If LastNode is set the
+// digest is finalized as the last node of its tree level.
+type Digest struct {
+	h     [8]uint32
+	c     [2]uint32
+	block [BlockSize]byte
+	off   int
+
+	iv       [8]uint32
+	key      [BlockSize]byte
+	keyLen   int
+	size     int
+	LastNode bool
+}
+
+// New returns a new Digest computing the BLAKE2s checksum using
+// the parameters p and the (optional) key. The key must not be
+// longer than 32 bytes and the digest size must be between 1 and
+// 32 bytes. Otherwise this function panics.
+func New(p *Params, key []byte) *Digest {
+	if k := len(key); k > KeySize {
+		panic("hydrogen/internal/blake2s: invalid key size " + strconv.Itoa(k))
+	}
+	if s := p.DigestSize; s == 0 || s > Size {
+		panic("hydrogen/internal/blake2s: invalid digest size " + strconv.Itoa(int(s)))
+	}
+	var block [32]byte
+	block[0] = p.DigestSize
+	block[1] = byte(len(key))
+	block[2] = p.Fanout
+	block[3] = p.Depth
+	binary.LittleEndian.PutUint32(block[4:], p.LeafLength)
+	binary.LittleEndian.PutUint32(block[8:], p.NodeOffset)
+	binary.LittleEndian.PutUint16(block[12:], p.XOFLength)
+	block[14] = p.NodeDepth
+	block[15] = p.InnerLength
+	copy(block[16:], p.Salt[:])
+	copy(block[24:], p.Personal[:])
+
+	d := &Digest{size: int(p.DigestSize), keyLen: len(key)}
+	for i := range d.iv {
+		d.iv[i] = iv[i] ^ binary.LittleEndian.Uint32(block[4*i:])
+	}
+	copy(d.key[:], key)
+	d.Reset()
+	return d
+}
+
+// Size returns the digest size in bytes.
+func (d *Digest) Size() int { return d.size }
+
+// BlockSize returns the blocksize of BLAKE2s in bytes.
+func (d *Digest) BlockSize() int { return BlockSize }
+
+// Reset resets the Digest to its initial state. It does
+// not modify the LastNode flag.
+func (d *Digest) Reset() {
+	d.h = d.iv
+	d.c[0], d.c[1] = 0, 0
+	d.off = 0
+	if d.keyLen > 0 {
+		d.block = d.key
+		d.off = BlockSize
+	}
+}
+
+// Write adds more data to the running hash. It never returns an error.
+func (d *Digest) Write(p []byte) (n int, err error) {
+	n = len(p)
+
+	if d.off > 0 {
+		dif := BlockSize - d.off
+		if n <= dif {
+			d.off += copy(d.block[d.off:], p)
+			return
+		}
+		copy(d.block[d.off:], p[:dif])
+		hashBlocks(&(d.h), &(d.c), 0, 0, d.block[:])
+		d.off = 0
+		p = p[dif:]
+	}
+	// Keep the last (possibly full) block - it must be processed by Sum.
+	if nn := ((len(p) - 1) / BlockSize) * BlockSize; nn > 0 {
+		hashBlocks(&(d.h), &(d.c), 0, 0, p[:nn])
+		p = p[nn:]
+	}
+	if len(p) > 0 {
+		d.off = copy(d.block[:], p)
+	}
+	return
+}
+
+// Sum appends the current checksum to b and returns the resulting
+// slice. It does not change the underlying hash state.
+func (d *Digest) Sum(b []byte) []byte {
+	var out [Size]byte
+	h, c, block := d.h, d.c, d.block
+	for i := d.off; i < BlockSize; i++ {
+		block[i] = 0
+	}
+
+	var f1 uint32
+	if d.LastNode {
+		f1 = 0xffffffff
+	}
+	// hashBlocks adds BlockSize to the counter - but the last block
+	// contains only d.off bytes.
+	ctr := uint64(c[1])<<32 | uint64(c[0])
+	ctr += uint64(d.off) - BlockSize
+	c[0], c[1] = uint32(ctr), uint32(ctr>>32)
+	hashBlocks(&h, &c, 0xffffffff, f1, block[:])
+
+	for i, v := range h {
+		binary.LittleEndian.PutUint32(out[4*i:], v)
+	}
+	return append(b, out[:d.size]...)
+}
@@ -0,0 +1,9 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package blake2s
+
+func hashBlocks(h *[8]uint32, c *[2]uint32, f0, f1 uint32, blocks []byte) {
+	hashBlocksGeneric(h, c, f0, f1, blocks)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package blake2s
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+const (
+	unkeyed = iota
+	keyed
+	tree
+)
+
+func newDigest(mode int) *Digest {
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	switch mode {
+	case unkeyed:
+		return New(&Params{DigestSize: Size, Fanout: 1, Depth: 1}, nil)
+	case keyed:
+		return New(&Params{DigestSize: Size, Fanout: 1, Depth: 1}, key)
+	default:
+		p := &Params{
+			DigestSize:  20,
+			Fanout:      2,
+			Depth:       3,
+			LeafLength:  4096,
+			NodeOffset:  7,
+			XOFLength:   1234,
+			NodeDepth:   1,
+			InnerLength: 32,
+		}
+		copy(p.Salt[:], "saltsalt")
+		copy(p.Personal[:], "personal")
+		d := New(p, key[:16])
+		d.LastNode = true
+		return d
+	}
+}
+
+func TestVectors(t *testing.T) {
+	for i, v := range vectors {
+		msg := make([]byte, v.length)
+		for j := range msg {
+			msg[j] = byte(j % 251)
+		}
+		want := fromHex(v.sum)
+
+		h := newDigest(v.mode)
+		h.Write(msg)
+		if sum := h.Sum(nil); !bytes.Equal(sum, want) {
+			t.Errorf("%d (single write): got: %s - want: %s", i, hex.EncodeToString(sum), v.sum)
+		}
+
+		h.Reset()
+		for j := range msg {
+			h.Write(msg[j : j+1])
+		}
+		if sum := h.Sum(nil); !bytes.Equal(sum, want) {
+			t.Errorf("%d (multi write): got: %s - want: %s", i, hex.EncodeToString(sum), v.sum)
+		}
+	}
+}
+
+var vectors = []struct {
+	mode   int
+	length int
+	sum    string
+}{
+	{unkeyed, 0, "69217a3079908094e11121d042354a7c1f55b6482ca1a51e1b250dfd1ed0eef9"},
+	{keyed, 0, "48a8997da407876b3d79c0d92325ad3b89cbb754d86ab71aee047ad345fd2c49"},
+	{tree, 0, "a5bb817098332cf906c4fc708769a4173df40d88"},
+	{unkeyed, 1, "e34d74dbaf4ff4c6abd871cc220451d2ea2648846c7757fbaac82fe51ad64bea"},
+	{keyed, 1, "40d15fee7c328830166ac3f918650f807e7e01e177258cdc0a39b11f598066f1"},
+	{tree, 1, "620f464ca1679de878714a47b3b643cc48101e80"},
+	{unkeyed, 3, "e8f91c6ef232a041452ab0e149070cdd7dd1769e75b3a5921be37876c45c9900"},
+	{keyed, 3, "1d220dbe2ee134661fdf6d9e74b41704710556f2f6e5a091b227697445dbea6b"},
+	{tree, 3, "318390fb15e6f3dc0ba462a589b0a5ff8872e1dd"},
+	{unkeyed, 63, "e57cb79487dd57902432b250733813bd96a84efce59f650fac26e6696aefafc3"},
+	{keyed, 63, "c65382513f07460da39833cb666c5ed82e61b9e998f4b0c4287cee56c3cc9bcd"},
+	{tree, 63, "aa4b78f30636298659f5c79cf6f1e824d50849a1"},
+	{unkeyed, 64, "56f34e8b96557e90c1f24b52d0c89d51086acf1b00f634cf1dde9233b8eaaa3e"},
+	{keyed, 64, "8975b0577fd35566d750b362b0897a26c399136df07bababbde6203ff2954ed4"},
+	{tree, 64, "0c20d8c33b1ff831a6fc9003bf8e842518c1a4e9"},
+	{unkeyed, 65, "1b53ee94aaf34e4b159d48de352c7f0661d0a40edff95a0b1639b4090e974472"},
+	{keyed, 65, "21fe0ceb0052be7fb0f004187cacd7de67fa6eb0938d927677f2398c132317a8"},
+	{tree, 65, "a239554fc1c408d2999dce887ddb61561fa7a540"},
+	{unkeyed, 128, "1fa877de67259d19863a2a34bcc6962a2b25fcbf5cbecd7ede8f1fa36688a796"},
+	{keyed, 128, "0c311f38c35a4fb90d651c289d486856cd1413df9b0677f53ece2cd9e477c60a"},
+	{tree, 128, "63dcd313419b66eb3e8ed152fb25a54bbae80716"},
+	{unkeyed, 255, "d96772649409d6df967e34e7aee3902033b81d7d44f1943e0c0a5f8057f774b8"},
+	{keyed, 255, "1198d1da21a1ef3056099ef664dd9c6b06c482674dc334dbafd1627be358bfb5"},
+	{tree, 255, "2d2a18b82e4d562c6019184f27e49074e1509035"},
+}
+
+func benchWrite(size int, b *testing.B) {
+	h := New(&Params{DigestSize: Size, Fanout: 1, Depth: 1}, nil)
+	msg := make([]byte, size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Write(msg)
+	}
+}
+
+func BenchmarkWrite64(b *testing.B) { benchWrite(64, b) }
+func BenchmarkWrite1K(b *testing.B) { benchWrite(1024, b) }
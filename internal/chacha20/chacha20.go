@@ -6,7 +6,10 @@
 // stream ciphers.
 package chacha20
 
-import "strconv"
+import (
+	"encoding/binary"
+	"strconv"
+)
 
 const (
 	// KeySize is the size of the key in bytes.
@@ -34,6 +37,15 @@ var sigma = []byte{
 // Src and dst may be the same slice but otherwise should not overlap.
 // If len(dst) < len(src) this function panics.
 func XORKeyStream(dst, src, nonce, key []byte) {
+	XORKeyStreamAt(dst, src, nonce, key, 0)
+}
+
+// XORKeyStreamAt works like XORKeyStream but starts the keystream at the
+// given block counter. Therefore the keystream of a message can be computed
+// in independent parts - block i starts at byte offset 64*i.
+// The 12 byte nonce version of ChaCha20/12 supports only 32 bit counters.
+// If the counter exceeds 32 bits for a 12 byte nonce, this function panics.
+func XORKeyStreamAt(dst, src, nonce, key []byte, counter uint64) {
 	if k := len(key); k != KeySize {
 		panic("hydrogen/internal/chacha20: invalid key size " + strconv.Itoa(k))
 	}
@@ -45,12 +57,17 @@ func XORKeyStream(dst, src, nonce, key []byte) {
 	default:
 		panic("hydrogen/internal/chacha20: invalid nonce size " + strconv.Itoa(n))
 	case NonceSize:
+		if counter > 1<<32-1 {
+			panic("hydrogen/internal/chacha20: counter exceeds 32 bits")
+		}
 		copy(state[:16], sigma[:])
 		copy(state[16:48], key)
+		binary.LittleEndian.PutUint32(state[48:], uint32(counter))
 		copy(state[52:], nonce)
 	case XNonceSize:
 		copy(state[:16], sigma[:])
 		hChaCha20(state[16:48], nonce[:16], key)
+		binary.LittleEndian.PutUint64(state[48:], counter)
 		copy(state[56:], nonce[16:])
 	}
 	xorKeyStream(dst, src, &block, &state)
@@ -32,6 +32,22 @@ func TestVectors(t *testing.T) {
 	}
 }
 
+func TestXORKeyStreamAt(t *testing.T) {
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for _, nonce := range [][]byte{make([]byte, NonceSize), make([]byte, XNonceSize)} {
+		keystream := make([]byte, 64*10+17)
+		XORKeyStream(keystream, keystream, nonce, key)
+
+		for _, block := range []int{0, 1, 3, 10} {
+			dst := make([]byte, len(keystream)-64*block)
+			XORKeyStreamAt(dst, dst, nonce, key, uint64(block))
+			if !bytes.Equal(dst, keystream[64*block:]) {
+				t.Errorf("nonce size %d: keystream at block %d does not match", len(nonce), block)
+			}
+		}
+	}
+}
+
 var vectors = []struct {
 	key, nonce, keystream string
 }{
@@ -0,0 +1,90 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package kdf
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"strconv"
+
+	"github.com/aead/hydrogen/auth"
+	"github.com/aead/hydrogen/secretbox"
+)
+
+// HKDFMaxSize is the maximum number of bytes HKDF-SHA256 can derive.
+const HKDFMaxSize = 255 * sha256.Size
+
+// HKDFExtract computes the pseudo-random key PRK = HMAC-SHA256(salt, secret)
+// as specified in RFC 5869. If the salt is empty, 32 zero bytes are used.
+func HKDFExtract(secret, salt []byte) []byte {
+	if len(salt) == 0 {
+		salt = make([]byte, sha256.Size)
+	}
+	mac := hmac.New(sha256.New, salt)
+	mac.Write(secret)
+	return mac.Sum(nil)
+}
+
+// HKDFExpand expands the pseudo-random key prk into length bytes using the
+// given info as specified in RFC 5869. The prk must be at least 32 bytes long
+// and the length must not be greater than HKDFMaxSize. Otherwise this function
+// panics.
+func HKDFExpand(prk, info []byte, length int) []byte {
+	if p := len(prk); p < sha256.Size {
+		panic("hydrogen/kdf: invalid PRK size " + strconv.Itoa(p))
+	}
+	if length < 0 || length > HKDFMaxSize {
+		panic("hydrogen/kdf: invalid HKDF output size " + strconv.Itoa(length))
+	}
+	out := make([]byte, 0, length+sha256.Size)
+	mac := hmac.New(sha256.New, prk)
+	var t []byte
+	for i := byte(1); len(out) < length; i++ {
+		// T(i) = HMAC-SHA256(prk, T(i-1) || info || i)
+		mac.Reset()
+		mac.Write(t)
+		mac.Write(info)
+		mac.Write([]byte{i})
+		t = mac.Sum(t[:0])
+		out = append(out, t...)
+	}
+	return out[:length]
+}
+
+// HKDF derives length bytes from the secret using the salt and info.
+// It is equal to HKDFExpand(HKDFExtract(secret, salt), info, length).
+func HKDF(secret, salt, info []byte, length int) []byte {
+	return HKDFExpand(HKDFExtract(secret, salt), info, length)
+}
+
+// HKDFInfo maps a hydrogen context and a purpose to an HKDF info string:
+//
+//	info = "hydrogen/" || purpose || "/" || context
+//
+// For example the secretbox key for the context "example1" is derived
+// with the info string "hydrogen/secretbox/example1". The context must
+// be 8 bytes long. Otherwise this function panics.
+func HKDFInfo(context []byte, purpose string) []byte {
+	if c := len(context); c != 8 {
+		panic("hydrogen/kdf: invalid context size " + strconv.Itoa(c))
+	}
+	info := make([]byte, 0, len("hydrogen/")+len(purpose)+1+len(context))
+	info = append(info, "hydrogen/"...)
+	info = append(info, purpose...)
+	info = append(info, '/')
+	return append(info, context...)
+}
+
+// HKDFSecretboxKey derives a secretbox key from the secret and salt
+// using the info string HKDFInfo(context, "secretbox").
+func HKDFSecretboxKey(secret, salt, context []byte) []byte {
+	return HKDF(secret, salt, HKDFInfo(context, "secretbox"), secretbox.KeySize)
+}
+
+// HKDFAuthKey derives an auth key from the secret and salt using
+// the info string HKDFInfo(context, "auth").
+func HKDFAuthKey(secret, salt, context []byte) []byte {
+	return HKDF(secret, salt, HKDFInfo(context, "auth"), auth.KeySize)
+}
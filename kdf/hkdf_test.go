@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package kdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+// Test vectors from RFC 5869 - Appendix A.1 - A.3
+var hkdfVectors = []struct {
+	secret, salt, info string
+	length             int
+	prk, okm           string
+}{
+	{
+		secret: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		salt:   "000102030405060708090a0b0c",
+		info:   "f0f1f2f3f4f5f6f7f8f9",
+		length: 42,
+		prk:    "077709362c2e32df0ddc3f0dc47bba6390b6c73bb50f9c3122ec844ad7c2b3e5",
+		okm:    "3cb25f25faacd57a90434f64d0362f2a2d2d0a90cf1a5a4c5db02d56ecc4c5bf34007208d5b887185865",
+	},
+	{
+		secret: "000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f202122232425262728292a2b2c2d2e2f303132333435363738393a3b3c3d3e3f404142434445464748494a4b4c4d4e4f",
+		salt:   "606162636465666768696a6b6c6d6e6f707172737475767778797a7b7c7d7e7f808182838485868788898a8b8c8d8e8f909192939495969798999a9b9c9d9e9fa0a1a2a3a4a5a6a7a8a9aaabacadaeaf",
+		info:   "b0b1b2b3b4b5b6b7b8b9babbbcbdbebfc0c1c2c3c4c5c6c7c8c9cacbcccdcecfd0d1d2d3d4d5d6d7d8d9dadbdcdddedfe0e1e2e3e4e5e6e7e8e9eaebecedeeeff0f1f2f3f4f5f6f7f8f9fafbfcfdfeff",
+		length: 82,
+		prk:    "06a6b88c5853361a06104c9ceb35b45cef760014904671014a193f40c15fc244",
+		okm:    "b11e398dc80327a1c8e7f78c596a49344f012eda2d4efad8a050cc4c19afa97c59045a99cac7827271cb41c65e590e09da3275600c2f09b8367793a9aca3db71cc30c58179ec3e87c14c01d5c1f3434f1d87",
+	},
+	{
+		secret: "0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b0b",
+		salt:   "",
+		info:   "",
+		length: 42,
+		prk:    "19ef24a32c717b167f33a91d6f648bdf96596776afdb6377ac434c1c293ccb04",
+		okm:    "8da4e775a563c18f715f802a063c5a31b8a11f5c5ee1879ec3454e5f3c738d2d9d201395faa4b61a96c8",
+	},
+}
+
+func TestHKDFVectors(t *testing.T) {
+	for i, v := range hkdfVectors {
+		prk := HKDFExtract(fromHex(v.secret), fromHex(v.salt))
+		if !bytes.Equal(prk, fromHex(v.prk)) {
+			t.Errorf("%d (extract): got:  %s - want: %s", i, hex.EncodeToString(prk), v.prk)
+		}
+		okm := HKDFExpand(prk, fromHex(v.info), v.length)
+		if !bytes.Equal(okm, fromHex(v.okm)) {
+			t.Errorf("%d (expand): got:  %s - want: %s", i, hex.EncodeToString(okm), v.okm)
+		}
+	}
+}
+
+func TestHKDFKeys(t *testing.T) {
+	secret, salt, context := []byte("shared secret"), []byte("salt"), []byte("libtests")
+
+	if info := string(HKDFInfo(context, "secretbox")); info != "hydrogen/secretbox/libtests" {
+		t.Fatalf("HKDFInfo returned unexpected info string %q", info)
+	}
+	key := HKDFSecretboxKey(secret, salt, context)
+	if want := "98d2ba3f5a47eefec10cdd782cb9a7b86f35b5acbe703976721ec27b1e029f98"; hex.EncodeToString(key) != want {
+		t.Errorf("secretbox key: got:  %s - want: %s", hex.EncodeToString(key), want)
+	}
+	key = HKDFAuthKey(secret, salt, context)
+	if want := "f8291964be18b766f3e3dbee222e5b31"; hex.EncodeToString(key) != want {
+		t.Errorf("auth key: got:  %s - want: %s", hex.EncodeToString(key), want)
+	}
+}
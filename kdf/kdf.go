@@ -0,0 +1,75 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package kdf provides functions to derive subkeys from a
+// secret master key.
+//
+// A subkey is identified by its length, a 64 bit id and an 8 byte
+// context. Subkeys with different lengths, ids or contexts are
+// independent from each other. Given a subkey it is not possible
+// to compute the master key or any other subkey.
+package kdf
+
+import (
+	"encoding/binary"
+	"io"
+	"strconv"
+
+	"github.com/aead/hydrogen/internal/chacha20"
+)
+
+const (
+	// KeySize is the size of the master key in bytes.
+	KeySize = 32
+	// MinSize is the minimum size of a subkey in bytes.
+	MinSize = 16
+	// MaxSize is the maximum size of a subkey in bytes.
+	MaxSize = 65535
+)
+
+// GenerateMasterKey returns a random master key.
+// Therefore the given reader must return random data.
+// This function returns a non-nil error if the given reader
+// fails to provide enough data. In this case the returned
+// key is nil must not used.
+func GenerateMasterKey(rand io.Reader) (key []byte, err error) {
+	key = make([]byte, KeySize)
+	_, err = io.ReadFull(rand, key)
+	if err != nil {
+		key = nil
+	}
+	return
+}
+
+// DeriveFromKey derives the subkey with the given length and id from
+// the master key using the provided context. The subkey length must be
+// between MinSize and MaxSize, the context must be 8 and the master key
+// 32 bytes long. Otherwise this function panics.
+func DeriveFromKey(subkeyLen int, subkeyID uint64, context, masterKey []byte) []byte {
+	if subkeyLen < MinSize || subkeyLen > MaxSize {
+		panic("hydrogen/kdf: invalid subkey size " + strconv.Itoa(subkeyLen))
+	}
+	if k := len(masterKey); k != KeySize {
+		panic("hydrogen/kdf: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/kdf: invalid context size " + strconv.Itoa(c))
+	}
+
+	// key = HChaCha12(id || context, masterKey)
+	var key [chacha20.KeySize]byte
+	var nonce [16]byte
+	binary.LittleEndian.PutUint64(nonce[:], subkeyID)
+	copy(nonce[8:], context)
+	chacha20.HChaCha20(key[:], nonce[:], masterKey)
+
+	// subkey = ChaCha12(len || {0}, key)
+	subkey := make([]byte, subkeyLen)
+	for i := range nonce {
+		nonce[i] = 0
+	}
+	binary.LittleEndian.PutUint16(nonce[:], uint16(subkeyLen))
+	chacha20.XORKeyStream(subkey, subkey, nonce[:chacha20.NonceSize], key[:])
+	return subkey
+}
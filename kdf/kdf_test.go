@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package kdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestVectors(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	for i, v := range vectors {
+		subkey := DeriveFromKey(v.size, v.id, context, key)
+		if !bytes.Equal(subkey, fromHex(v.subkey)) {
+			t.Errorf("%d: got:  %s - want: %s", i, hex.EncodeToString(subkey), v.subkey)
+		}
+	}
+}
+
+var vectors = []struct {
+	size   int
+	id     uint64
+	subkey string
+}{
+	{16, 0, "867bc880fb26a57c4fc041ca6417e307"},
+	{32, 0, "7fd11bc3cc56c73bced748b00e8f531166e6e3146f56e0c214b1d97b4e7f1918"},
+	{32, 1, "330bee571e09ba293eaf2e77e197a7dd1620a5d53db65ebdafb88b13e00f82bc"},
+	{64, 12345, "bfd3467f60af5ac4e6e60fadaceda31308449006991fe5b9ea87930a2ace7b0d60d0ea0fd53d7b514d74c17f26979f4c17cb150a444a502ce03c0c3f21215ca3"},
+	{100, 18446744073709551615, "b81b80b5c494b3636a203bc4465e2d3123338b21cd1280fa1b1f8537949e2bc1d978f83f155a3d445661de5f2819aaec5fea9558a72725430004dc990d163344fce6c64a1f5361b791b7833e619d32bb6f189a3feb5d83590eecf637aff920f0f5ae33c1"},
+}
+
+func TestInvalidSizes(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	tests := []func(){
+		func() { DeriveFromKey(MinSize-1, 0, context, key) },
+		func() { DeriveFromKey(MaxSize+1, 0, context, key) },
+		func() { DeriveFromKey(32, 0, context[:7], key) },
+		func() { DeriveFromKey(32, 0, context, key[:16]) },
+	}
+	for i, f := range tests {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: DeriveFromKey accepted invalid argument", i)
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func benchDerive(size int, b *testing.B) {
+	context := []byte("runbench")
+	key := make([]byte, KeySize)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DeriveFromKey(size, uint64(i), context, key)
+	}
+}
+
+func BenchmarkDerive32(b *testing.B) { benchDerive(32, b) }
+func BenchmarkDerive64(b *testing.B) { benchDerive(64, b) }
@@ -0,0 +1,134 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package kdf
+
+import (
+	"encoding/binary"
+	"strconv"
+	"sync"
+
+	"github.com/aead/hydrogen/hash"
+)
+
+const (
+	kindName byte = iota + 1
+	kindIndex
+)
+
+// Component is one element of a key path - for example
+// Name("tenant") or Index(42).
+type Component struct {
+	kind  byte
+	name  string
+	index uint64
+}
+
+// Name returns a path component identified by a string.
+func Name(name string) Component { return Component{kind: kindName, name: name} }
+
+// Index returns a path component identified by a number.
+// Index(42) and Name("42") are different components.
+func Index(index uint64) Component { return Component{kind: kindIndex, index: index} }
+
+// String returns a human-readable representation of c.
+func (c Component) String() string {
+	if c.kind == kindIndex {
+		return "#" + strconv.FormatUint(c.index, 10)
+	}
+	return strconv.Quote(c.name)
+}
+
+// encode returns the unambiguous encoding of c:
+// kind || le64(len(name)) || name or kind || le64(index)
+func (c Component) encode() []byte {
+	b := make([]byte, 9, 9+len(c.name))
+	b[0] = c.kind
+	if c.kind == kindIndex {
+		binary.LittleEndian.PutUint64(b[1:], c.index)
+		return b
+	}
+	binary.LittleEndian.PutUint64(b[1:], uint64(len(c.name)))
+	return append(b, c.name...)
+}
+
+// Node is a node of a hierarchical key tree. Every node holds a
+// 32 byte key and derives the keys of its children from it, one
+// path component at a time:
+//
+//	label = hash.Sum(encode(component), context)
+//	tmp   = DeriveFromKey(32, label[16:24], label[24:32], parent)
+//	child = DeriveFromKey(32, label[0:8], label[8:16], tmp)
+//
+// A node can be handed to a service without exposing its parent or
+// siblings - it is not possible to derive a parent key from a child.
+// Derived nodes are cached. A Node is safe for concurrent use.
+type Node struct {
+	key     []byte
+	context []byte
+
+	mu       sync.Mutex
+	children map[Component]*Node
+}
+
+// NewNode returns a new Node with the given context and key. The key
+// can either be a master key or the key of a node of an existing tree.
+// The context must be 8 and the key 32 bytes long. Otherwise this
+// function panics.
+func NewNode(context, key []byte) *Node {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/kdf: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/kdf: invalid context size " + strconv.Itoa(c))
+	}
+	n := &Node{
+		key:      make([]byte, KeySize),
+		context:  make([]byte, 8),
+		children: make(map[Component]*Node),
+	}
+	copy(n.key, key)
+	copy(n.context, context)
+	return n
+}
+
+// Key returns a copy of the key of n.
+func (n *Node) Key() []byte {
+	key := make([]byte, KeySize)
+	copy(key, n.key)
+	return key
+}
+
+// Child returns the child node of n identified by c.
+func (n *Node) Child(c Component) *Node {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if child, ok := n.children[c]; ok {
+		return child
+	}
+	var label [32]byte
+	hash.Sum(label[:], c.encode(), n.context, nil)
+	tmp := DeriveFromKey(KeySize, binary.LittleEndian.Uint64(label[16:]), label[24:], n.key)
+	key := DeriveFromKey(KeySize, binary.LittleEndian.Uint64(label[0:]), label[8:16], tmp)
+
+	child := NewNode(n.context, key)
+	n.children[c] = child
+	return child
+}
+
+// Derive returns the descendant of n identified by the given path.
+// If the path is empty, Derive returns n.
+func (n *Node) Derive(path ...Component) *Node {
+	for _, c := range path {
+		n = n.Child(c)
+	}
+	return n
+}
+
+// Subkey derives a subkey with the given length and id from the key
+// of n. It is equal to DeriveFromKey(subkeyLen, subkeyID, context, n.Key()).
+func (n *Node) Subkey(subkeyLen int, subkeyID uint64) []byte {
+	return DeriveFromKey(subkeyLen, subkeyID, n.context, n.key)
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package kdf
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestNode(t *testing.T) {
+	context := []byte("libtests")
+	root := NewNode(context, make([]byte, KeySize))
+
+	path := []Component{Name("tenant"), Index(42), Name("db"), Name("orders")}
+	orders := root.Derive(path...)
+	if orders != root.Child(Name("tenant")).Child(Index(42)).Derive(path[2:]...) {
+		t.Fatal("Derive did not return the cached node")
+	}
+	if root.Derive() != root {
+		t.Fatal("Derive with empty path did not return the node itself")
+	}
+
+	// A service holding the key of a subtree derives the same keys.
+	tenant := NewNode(context, root.Derive(path[:2]...).Key())
+	if !bytes.Equal(tenant.Derive(path[2:]...).Key(), orders.Key()) {
+		t.Fatal("Subtree key derived different descendant")
+	}
+
+	keys := [][]byte{
+		root.Key(),
+		orders.Key(),
+		root.Child(Name("42")).Key(),
+		root.Child(Index(42)).Key(),
+		root.Child(Name("")).Key(),
+		root.Child(Index(0)).Key(),
+		root.Derive(Name("tenant"), Name("42")).Key(),
+		root.Derive(Name("tenant4"), Name("2")).Key(),
+		NewNode([]byte("wrongctx"), root.Key()).Child(Index(42)).Key(),
+	}
+	for i := range keys {
+		for j := i + 1; j < len(keys); j++ {
+			if bytes.Equal(keys[i], keys[j]) {
+				t.Fatalf("key %d and %d are equal", i, j)
+			}
+		}
+	}
+
+	if !bytes.Equal(orders.Subkey(32, 7), DeriveFromKey(32, 7, context, orders.Key())) {
+		t.Fatal("Subkey does not match DeriveFromKey")
+	}
+}
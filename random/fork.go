@@ -0,0 +1,11 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import "os"
+
+// pidForkID returns the process id as fork id. It is the fallback
+// if no cheaper fork detection is available - os.Getpid is a syscall.
+func pidForkID() uint64 { return uint64(os.Getpid()) }
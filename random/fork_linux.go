@@ -0,0 +1,59 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+)
+
+const madvWipeOnFork = 18 // MADV_WIPEONFORK - Linux 4.14 and newer
+
+// forkMarker points into a page which the kernel replaces with zeros
+// in a forked child process. It contains the current, non-zero fork id.
+// The forkEpoch in ordinary memory is copied to the child and used to
+// compute the next fork id.
+var (
+	forkMarker *uint64
+	forkEpoch  uint64
+	forkMu     sync.Mutex // serializes the update of the fork id after a fork
+)
+
+func init() {
+	page, err := syscall.Mmap(-1, 0, syscall.Getpagesize(), syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_PRIVATE|syscall.MAP_ANON)
+	if err != nil {
+		return
+	}
+	if err = syscall.Madvise(page, madvWipeOnFork); err != nil {
+		syscall.Munmap(page)
+		return
+	}
+	forkEpoch = 1
+	forkMarker = (*uint64)(unsafe.Pointer(&page[0]))
+	atomic.StoreUint64(forkMarker, forkEpoch)
+}
+
+// forkID returns a value which changes whenever the process is forked.
+// If the kernel supports MADV_WIPEONFORK it does not need a syscall.
+func forkID() uint64 {
+	if forkMarker == nil {
+		return pidForkID()
+	}
+	if id := atomic.LoadUint64(forkMarker); id != 0 {
+		return id
+	}
+
+	// The marker page has been wiped - this is a forked child.
+	forkMu.Lock()
+	defer forkMu.Unlock()
+	if id := atomic.LoadUint64(forkMarker); id != 0 {
+		return id
+	}
+	forkEpoch++
+	atomic.StoreUint64(forkMarker, forkEpoch)
+	return forkEpoch
+}
@@ -0,0 +1,11 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+//go:build !linux
+// +build !linux
+
+package random
+
+// forkID returns a value which changes whenever the process is forked.
+func forkID() uint64 { return pidForkID() }
@@ -0,0 +1,128 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	crand "crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/aead/hydrogen/internal/chacha20"
+)
+
+const (
+	poolSize       = 1024
+	reseedInterval = 1 << 20 // reseed after 1 MB of output
+)
+
+var zero [16]byte
+
+// Generator is a fast, forward-secure userspace CSPRNG.
+//
+// A Generator produces its output in chunks of ChaCha12 keystream.
+// The first 32 bytes of every chunk replace the current key, such that
+// a compromise of the state does not reveal previously generated data.
+// Furthermore a Generator reseeds itself with entropy from the operating
+// system after every MB of output and whenever it detects that the
+// process has been forked. On Linux the fork detection uses a page
+// marked with MADV_WIPEONFORK and does not require a syscall per Read.
+// Otherwise it compares the process id.
+//
+// A Generator is safe for concurrent use and can be used as the source
+// of randomness for e.g. secretbox.Encrypt or auth.GenerateKey.
+type Generator struct {
+	mu        sync.Mutex
+	key       [chacha20.KeySize]byte
+	pool      [poolSize]byte
+	off       int
+	generated uint64
+	forkID    uint64
+	entropy   io.Reader
+}
+
+// NewGenerator returns a new Generator seeded with entropy from
+// the operating system. It returns a non-nil error if the RNG
+// of the operating system fails.
+func NewGenerator() (*Generator, error) {
+	g := &Generator{entropy: crand.Reader}
+	if err := g.seed(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// Read fills p with random data. It returns a non-nil error only
+// if the process has been forked and the Generator fails to reseed
+// itself. In this case p must not be used.
+func (g *Generator) Read(p []byte) (n int, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if forkID() != g.forkID { // never share the stream with the parent process
+		if err = g.seed(); err != nil {
+			return
+		}
+	}
+	for len(p) > 0 {
+		if g.off == len(g.pool) {
+			g.refill()
+		}
+		c := copy(p, g.pool[g.off:])
+		for i := g.off; i < g.off+c; i++ {
+			g.pool[i] = 0
+		}
+		g.off += c
+		n += c
+		p = p[c:]
+	}
+	return
+}
+
+// seed replaces the entire state of g with fresh entropy.
+func (g *Generator) seed() error {
+	var key [chacha20.KeySize]byte
+	if _, err := io.ReadFull(g.entropy, key[:]); err != nil {
+		return err
+	}
+	g.key = key
+	for i := range g.pool {
+		g.pool[i] = 0
+	}
+	g.off = len(g.pool)
+	g.generated = 0
+	g.forkID = forkID()
+	return nil
+}
+
+// reseed mixes fresh entropy into the current key. If the RNG of the
+// operating system fails, the current key is kept and g tries to reseed
+// again with the next refill.
+func (g *Generator) reseed() {
+	var entropy [chacha20.KeySize]byte
+	if _, err := io.ReadFull(g.entropy, entropy[:]); err != nil {
+		return
+	}
+	for i := range g.key {
+		g.key[i] ^= entropy[i]
+	}
+	chacha20.HChaCha20(g.key[:], zero[:], g.key[:])
+	g.generated = 0
+}
+
+// refill regenerates the pool. The pool must only contain zeros.
+func (g *Generator) refill() {
+	if g.generated >= reseedInterval {
+		g.reseed()
+	}
+
+	// key || pool = ChaCha12(zero, key)
+	chacha20.XORKeyStream(g.pool[:], g.pool[:], zero[:chacha20.NonceSize], g.key[:])
+	copy(g.key[:], g.pool[:chacha20.KeySize])
+	for i := 0; i < chacha20.KeySize; i++ {
+		g.pool[i] = 0
+	}
+	g.off = chacha20.KeySize
+	g.generated += uint64(len(g.pool) - chacha20.KeySize)
+}
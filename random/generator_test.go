@@ -0,0 +1,118 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"bytes"
+	crand "crypto/rand"
+	"errors"
+	"testing"
+
+	"github.com/aead/hydrogen/secretbox"
+)
+
+type failingReader struct{}
+
+func (failingReader) Read([]byte) (int, error) { return 0, errors.New("entropy source failed") }
+
+func TestGenerator(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+
+	seen := make(map[string]bool)
+	buf := make([]byte, 16*211)
+	for i := 0; i < 16; i++ {
+		if n, err := g.Read(buf[:i*211]); n != i*211 || err != nil {
+			t.Fatalf("%d: Read failed: n = %d err = %v", i, n, err)
+		}
+		if i > 0 {
+			s := string(buf[:32])
+			if seen[s] {
+				t.Fatalf("%d: Generator repeated its output", i)
+			}
+			seen[s] = true
+		}
+	}
+	for i := 0; i < g.off; i++ {
+		if g.pool[i] != 0 {
+			t.Fatal("Generator did not erase returned random data")
+		}
+	}
+}
+
+func TestGeneratorReseed(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	g.refill()
+	key := g.key
+
+	g.generated = reseedInterval
+	g.entropy = failingReader{}
+	g.off = len(g.pool)
+	g.refill()
+	if g.generated != reseedInterval+poolSize-32 {
+		t.Fatal("Generator did not retry to reseed after a failure")
+	}
+	if bytes.Equal(key[:], g.key[:]) {
+		t.Fatal("Generator did not ratchet its key")
+	}
+
+	g.forkID = 0 // simulate a fork
+	if _, err = g.Read(make([]byte, 1)); err == nil {
+		t.Fatal("Generator did not fail to reseed after fork")
+	}
+}
+
+func TestGeneratorSecretbox(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatalf("Failed to create generator: %v", err)
+	}
+	key, err := secretbox.GenerateKey(g)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	context := []byte("libtests")
+	msg := []byte("Hello World")
+	ciphertext := make([]byte, len(msg)+secretbox.HeaderSize)
+	secretbox.Encrypt(ciphertext, msg, 0, g, context, key)
+	if err = secretbox.Decrypt(msg, ciphertext, 0, context, key); err != nil {
+		t.Fatalf("Failed to decrypt: %v", err)
+	}
+}
+
+func benchGenerator(size int, b *testing.B) {
+	g, err := NewGenerator()
+	if err != nil {
+		b.Fatal(err)
+	}
+	buf := make([]byte, size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		g.Read(buf)
+	}
+}
+
+func BenchmarkGenerator16(b *testing.B)   { benchGenerator(16, b) }
+func BenchmarkGenerator1024(b *testing.B) { benchGenerator(1024, b) }
+
+func benchCryptoRand(size int, b *testing.B) {
+	buf := make([]byte, size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		crand.Read(buf)
+	}
+}
+
+func BenchmarkCryptoRand16(b *testing.B)   { benchCryptoRand(16, b) }
+func BenchmarkCryptoRand1024(b *testing.B) { benchCryptoRand(1024, b) }
@@ -0,0 +1,110 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"errors"
+	"io"
+)
+
+// The cutoff values of the health tests assume a min-entropy of at
+// least 1 bit per byte and a false positive probability of 2^-20.
+// See: NIST SP 800-90B section 4.4
+const (
+	repetitionCutoff = 21  // 1 + ceil(20 / H)
+	proportionWindow = 512 // W for non-binary sources
+	proportionCutoff = 311 // 1 + CRITBINOM(W, 2^-H, 1 - 2^-20)
+)
+
+var (
+	// ErrRepetitionCount is returned by a HealthReader if the
+	// underlying reader returned the same byte too many times
+	// in a row.
+	ErrRepetitionCount = errors.New("hydrogen/random: repetition count test failed")
+
+	// ErrAdaptiveProportion is returned by a HealthReader if one
+	// byte value occurs too often within a window of 512 bytes.
+	ErrAdaptiveProportion = errors.New("hydrogen/random: adaptive proportion test failed")
+)
+
+// HealthReader wraps an io.Reader and continuously checks the data
+// passing through it using the repetition count and adaptive proportion
+// test of NIST SP 800-90B. It detects catastrophic failures of the
+// underlying source - like a stuck hardware RNG - but cannot prove that
+// the data is random.
+//
+// Once a test has failed, every subsequent Read returns the same error.
+type HealthReader struct {
+	r         io.Reader
+	onFailure func(error)
+	err       error
+	samples   uint64
+
+	last byte // repetition count test
+	run  int
+
+	sample byte // adaptive proportion test
+	count  int
+	index  int
+}
+
+// NewHealthReader returns a HealthReader reading from r. The onFailure
+// function can be nil. Otherwise it is called once with the error of
+// the first failing test.
+func NewHealthReader(r io.Reader, onFailure func(error)) *HealthReader {
+	return &HealthReader{r: r, onFailure: onFailure}
+}
+
+// Read reads up to len(p) bytes from the underlying reader and checks
+// them before returning. If a test fails, Read returns a non-nil error
+// and p must not be used.
+func (h *HealthReader) Read(p []byte) (n int, err error) {
+	if h.err != nil {
+		return 0, h.err
+	}
+	n, err = h.r.Read(p)
+	for _, v := range p[:n] {
+		if testErr := h.test(v); testErr != nil {
+			h.err = testErr
+			if h.onFailure != nil {
+				h.onFailure(testErr)
+			}
+			return 0, testErr
+		}
+	}
+	return n, err
+}
+
+func (h *HealthReader) test(v byte) error {
+	if h.samples == 0 {
+		h.last, h.run = v, 1
+		h.sample, h.count, h.index = v, 1, 1
+		h.samples++
+		return nil
+	}
+	h.samples++
+
+	if v == h.last {
+		h.run++
+		if h.run >= repetitionCutoff {
+			return ErrRepetitionCount
+		}
+	} else {
+		h.last, h.run = v, 1
+	}
+
+	if h.index == proportionWindow {
+		h.sample, h.count, h.index = v, 1, 1
+		return nil
+	}
+	if v == h.sample {
+		h.count++
+		if h.count >= proportionCutoff {
+			return ErrAdaptiveProportion
+		}
+	}
+	h.index++
+	return nil
+}
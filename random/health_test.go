@@ -0,0 +1,76 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"bytes"
+	"io"
+	"testing"
+)
+
+type stuckReader byte
+
+func (r stuckReader) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(r)
+	}
+	return len(p), nil
+}
+
+// biasedReader returns a fixed value in period-1 of period bytes
+// but never repeats a byte more than period-1 times in a row.
+type biasedReader struct{ n, period int }
+
+func (r *biasedReader) Read(p []byte) (int, error) {
+	for i := range p {
+		if r.n%r.period == r.period-1 {
+			p[i] = byte(r.n)
+		} else {
+			p[i] = 0xAA
+		}
+		r.n++
+	}
+	return len(p), nil
+}
+
+func TestHealthReader(t *testing.T) {
+	buf := make([]byte, 4096)
+
+	h := NewHealthReader(Reader, nil)
+	for i := 0; i < 64; i++ {
+		if _, err := io.ReadFull(h, buf); err != nil {
+			t.Fatalf("%d: HealthReader rejected random data: %v", i, err)
+		}
+	}
+
+	var hookErr error
+	h = NewHealthReader(stuckReader(0), func(err error) { hookErr = err })
+	if _, err := io.ReadFull(h, buf[:repetitionCutoff-1]); err != nil {
+		t.Fatalf("HealthReader failed too early: %v", err)
+	}
+	if _, err := io.ReadFull(h, buf[:1]); err != ErrRepetitionCount {
+		t.Fatalf("HealthReader did not detect stuck reader: %v", err)
+	}
+	if hookErr != ErrRepetitionCount {
+		t.Fatalf("HealthReader did not call failure hook: %v", hookErr)
+	}
+	if _, err := h.Read(buf); err != ErrRepetitionCount {
+		t.Fatalf("HealthReader did not remember failure: %v", err)
+	}
+
+	// A value occurring in 2 of 3 bytes exceeds the cutoff
+	// for a min-entropy of 1 bit per byte.
+	for _, period := range []int{10, 3} {
+		h = NewHealthReader(&biasedReader{period: period}, nil)
+		if _, err := io.ReadFull(h, buf); err != ErrAdaptiveProportion {
+			t.Fatalf("HealthReader did not detect biased reader with period %d: %v", period, err)
+		}
+	}
+
+	h = NewHealthReader(bytes.NewReader([]byte{1, 2, 3}), nil)
+	if n, err := h.Read(buf); n != 3 || err != nil {
+		t.Fatalf("HealthReader modified result of underlying reader: n = %d err = %v", n, err)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package random provides functions to generate random data.
+//
+// The non-deterministic functions of this package use the
+// cryptographically secure PRNG of the operating system.
+// BufDeterministic expands a seed into an arbitrary long
+// pseudo-random sequence using ChaCha12.
+package random
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"io"
+	"strconv"
+
+	"github.com/aead/hydrogen/internal/chacha20"
+)
+
+// SeedSize is the size of the seed of BufDeterministic in bytes.
+const SeedSize = 32
+
+// Reader is a global, shared instance of a cryptographically
+// secure random number generator. It is safe for concurrent use.
+var Reader io.Reader = reader{}
+
+type reader struct{}
+
+func (reader) Read(p []byte) (int, error) { return crand.Read(p) }
+
+// Read is a helper function that calls io.ReadFull with Reader.
+// On return, n == len(b) if and only if err == nil.
+func Read(b []byte) (n int, err error) {
+	return io.ReadFull(Reader, b)
+}
+
+// Bytes fills b with random data. This function panics if
+// the random number generator of the operating system fails.
+func Bytes(b []byte) {
+	if _, err := Read(b); err != nil {
+		panic("hydrogen/random: failed to read random data: " + err.Error())
+	}
+}
+
+// Uint32 returns a random 32 bit unsigned integer. This function
+// panics if the random number generator of the operating system fails.
+func Uint32() uint32 {
+	var b [4]byte
+	Bytes(b[:])
+	return binary.LittleEndian.Uint32(b[:])
+}
+
+var nonce = []byte("hydro_random")
+
+// BufDeterministic fills out with pseudo-random data derived from
+// the given seed. The same seed always produces the same sequence.
+// The seed must be 32 bytes long, otherwise this function panics.
+func BufDeterministic(out, seed []byte) {
+	if s := len(seed); s != SeedSize {
+		panic("hydrogen/random: invalid seed size " + strconv.Itoa(s))
+	}
+	for i := range out {
+		out[i] = 0
+	}
+	chacha20.XORKeyStream(out, out, nonce, seed)
+}
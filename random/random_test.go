@@ -0,0 +1,74 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func fromHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
+
+func TestBufDeterministic(t *testing.T) {
+	seed := fromHex("000102030405060708090a0b0c0d0e0f101112131415161718191a1b1c1d1e1f")
+	want := fromHex(deterministicVector)
+
+	out := make([]byte, len(want))
+	BufDeterministic(out, seed)
+	if !bytes.Equal(out, want) {
+		t.Fatalf("got:  %s\nwant: %s", hex.EncodeToString(out), deterministicVector)
+	}
+
+	for i := range out {
+		out[i] = 0xff
+	}
+	for i := 0; i < len(want); i++ {
+		BufDeterministic(out[:i], seed)
+		if !bytes.Equal(out[:i], want[:i]) {
+			t.Fatalf("%d: BufDeterministic is not a prefix of the longer output", i)
+		}
+	}
+}
+
+func TestBytes(t *testing.T) {
+	a, b := make([]byte, 32), make([]byte, 32)
+	Bytes(a)
+	Bytes(b)
+	if bytes.Equal(a, b) {
+		t.Fatal("Bytes returned the same data twice")
+	}
+	if n, err := Read(a); n != len(a) || err != nil {
+		t.Fatalf("Read failed: n = %d err = %v", n, err)
+	}
+}
+
+const deterministicVector = "0a833d053e01ec508c6e3faf23f0b0339b636bfb077f691b6f0b640d54fdc0f69c0d1730f65669b89dcfa2d9064ae4d65327a413c9d97726f8bf35dfce2e97dbf1f9124ccaef538b3c0c1fae98933701d06f944753b00ed4ef23c76e2ac128a754d29174"
+
+func BenchmarkUint32(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Uint32()
+	}
+}
+
+func benchBufDeterministic(size int, b *testing.B) {
+	seed := make([]byte, SeedSize)
+	buf := make([]byte, size)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		BufDeterministic(buf, seed)
+	}
+}
+
+func BenchmarkBufDeterministic64(b *testing.B)   { benchBufDeterministic(64, b) }
+func BenchmarkBufDeterministic1024(b *testing.B) { benchBufDeterministic(1024, b) }
@@ -0,0 +1,123 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+const maxUint32 = 1<<32 - 1
+
+// Uniform returns a uniformly distributed random number in [0, upperBound).
+// In contrast to Uint32() % upperBound the result is not biased. If
+// upperBound < 2 this function returns 0.
+func Uniform(upperBound uint32) uint32 {
+	if upperBound < 2 {
+		return 0
+	}
+	// min = 2^32 % upperBound - reject all values smaller than min
+	// such that the remaining range is a multiple of upperBound.
+	min := -upperBound % upperBound
+	r := Uint32()
+	for r < min {
+		r = Uint32()
+	}
+	return r % upperBound
+}
+
+func uniform64(upperBound uint64) uint64 {
+	if upperBound < 2 {
+		return 0
+	}
+	var b [8]byte
+	min := -upperBound % upperBound
+	Bytes(b[:])
+	r := binary.LittleEndian.Uint64(b[:])
+	for r < min {
+		Bytes(b[:])
+		r = binary.LittleEndian.Uint64(b[:])
+	}
+	return r % upperBound
+}
+
+// Shuffle pseudo-randomizes the order of n elements using the
+// Fisher-Yates algorithm. The swap function swaps the elements
+// with indexes i and j. This function panics if n < 0 or if
+// n does not fit into 32 bits.
+func Shuffle(n int, swap func(i, j int)) {
+	if n < 0 || uint64(n) > maxUint32 {
+		panic("hydrogen/random: invalid number of elements " + strconv.Itoa(n))
+	}
+	for i := n - 1; i > 0; i-- {
+		j := int(Uniform(uint32(i + 1)))
+		swap(i, j)
+	}
+}
+
+// Perm returns a random permutation of the integers [0, n).
+// This function panics if n < 0 or if n does not fit into 32 bits.
+func Perm(n int) []int {
+	if n < 0 || uint64(n) > maxUint32 {
+		panic("hydrogen/random: invalid number of elements " + strconv.Itoa(n))
+	}
+	p := make([]int, n)
+	for i := range p {
+		p[i] = i
+	}
+	Shuffle(n, func(i, j int) { p[i], p[j] = p[j], p[i] })
+	return p
+}
+
+// Sample returns k distinct integers chosen uniformly at random from
+// [0, n) in random order. This function panics if k < 0, if k > n or
+// if n does not fit into 32 bits.
+func Sample(k, n int) []int {
+	if n < 0 || uint64(n) > maxUint32 {
+		panic("hydrogen/random: invalid number of elements " + strconv.Itoa(n))
+	}
+	if k < 0 || k > n {
+		panic("hydrogen/random: invalid sample size " + strconv.Itoa(k))
+	}
+
+	// Floyd's algorithm selects k distinct values with exactly k
+	// calls to Uniform - independent of the size of n.
+	s := make([]int, 0, k)
+	seen := make(map[int]struct{}, k)
+	for j := n - k; j < n; j++ {
+		v := int(Uniform(uint32(j + 1)))
+		if _, ok := seen[v]; ok {
+			v = j
+		}
+		seen[v] = struct{}{}
+		s = append(s, v)
+	}
+	Shuffle(len(s), func(i, j int) { s[i], s[j] = s[j], s[i] })
+	return s
+}
+
+// Choice returns a random index i of weights with a probability
+// proportional to weights[i]. The selection takes the same time
+// for every returned index. This function panics if the sum of
+// all weights is 0.
+func Choice(weights []uint32) int {
+	var total uint64
+	for _, w := range weights {
+		total += uint64(w)
+	}
+	if total == 0 {
+		panic("hydrogen/random: sum of weights is 0")
+	}
+	r := uniform64(total)
+
+	var cum, index, done uint64
+	for i, w := range weights {
+		cum += uint64(w)
+		less := ((r - cum) >> 63) &^ done // 1 if r < cum and no index selected yet
+		index |= uint64(i) & -less
+		done |= less
+	}
+	return int(index)
+}
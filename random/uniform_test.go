@@ -0,0 +1,85 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package random
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestUniform(t *testing.T) {
+	if v := Uniform(0); v != 0 {
+		t.Fatalf("Uniform(0) returned %d", v)
+	}
+	if v := Uniform(1); v != 0 {
+		t.Fatalf("Uniform(1) returned %d", v)
+	}
+
+	const bound, samples = 10, 100000
+	var hist [bound]int
+	for i := 0; i < samples; i++ {
+		v := Uniform(bound)
+		if v >= bound {
+			t.Fatalf("Uniform(%d) returned %d", bound, v)
+		}
+		hist[v]++
+	}
+	for i, n := range hist {
+		if n < samples/bound*9/10 || n > samples/bound*11/10 {
+			t.Errorf("%d: unexpected frequency %d of %d samples", i, n, samples)
+		}
+	}
+}
+
+func TestPerm(t *testing.T) {
+	for _, n := range []int{0, 1, 2, 17, 1000} {
+		p := Perm(n)
+		sort.Ints(p)
+		for i, v := range p {
+			if i != v {
+				t.Fatalf("Perm(%d) is not a permutation", n)
+			}
+		}
+	}
+}
+
+func TestSample(t *testing.T) {
+	for _, v := range []struct{ k, n int }{{0, 0}, {0, 10}, {1, 1}, {5, 10}, {10, 10}, {100, 1 << 20}} {
+		s := Sample(v.k, v.n)
+		if len(s) != v.k {
+			t.Fatalf("Sample(%d, %d) returned %d elements", v.k, v.n, len(s))
+		}
+		seen := make(map[int]bool)
+		for _, x := range s {
+			if x < 0 || x >= v.n || seen[x] {
+				t.Fatalf("Sample(%d, %d) returned invalid or duplicate element %d", v.k, v.n, x)
+			}
+			seen[x] = true
+		}
+	}
+}
+
+func TestChoice(t *testing.T) {
+	weights := []uint32{0, 3, 0, 1, 0}
+	var hist [5]int
+	for i := 0; i < 40000; i++ {
+		hist[Choice(weights)]++
+	}
+	if hist[0] != 0 || hist[2] != 0 || hist[4] != 0 {
+		t.Fatalf("Choice selected an index with weight 0: %v", hist)
+	}
+	if hist[1] < 2*hist[3] || hist[1] > 4*hist[3] {
+		t.Fatalf("Choice returned unexpected distribution: %v", hist)
+	}
+	if i := Choice([]uint32{0, 0, 0xffffffff, 0xffffffff}); i != 2 && i != 3 {
+		t.Fatalf("Choice returned invalid index %d", i)
+	}
+}
+
+func BenchmarkUniform(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		Uniform(1000)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"crypto/cipher"
+	"encoding/binary"
+	"strconv"
+)
+
+// Seal encrypts and authenticates msg like Encrypt, appends the result
+// to dst and returns the updated slice. Seal uses the PRNG of the system.
+// To reuse msg's storage for the ciphertext, use msg[:0] as dst.
+// The context must be 8 and the key 32 bytes long, otherwise this
+// function panics.
+func Seal(dst, msg []byte, id uint64, context, key []byte) []byte {
+	var random [16]byte
+	readRandom(&random, nil)
+	return seal(dst, msg, nil, id, domainBox, &random, context, key)
+}
+
+// Open decrypts and verifies a ciphertext created by Seal or Encrypt,
+// appends the resulting message to dst and returns the updated slice.
+// To reuse ciphertext's storage for the message, use ciphertext[:0] as dst.
+// Open returns a non-nil error if the ciphertext could not be decrypted
+// with the given id, context and key. The context must be 8 and the key
+// 32 bytes long, otherwise this function panics.
+func Open(dst, ciphertext []byte, id uint64, context, key []byte) ([]byte, error) {
+	return open(dst, ciphertext, nil, id, domainBox, context, key)
+}
+
+func seal(dst, msg, ad []byte, id uint64, domain byte, random *[16]byte, context, key []byte) []byte {
+	return sealPrefixed(dst, nil, msg, ad, id, domain, random, context, key)
+}
+
+// sealPrefixed works like seal but writes prefix in front of the ciphertext.
+// The prefix is written after msg has been moved such that msg may overlap dst.
+func sealPrefixed(dst, prefix, msg, ad []byte, id uint64, domain byte, random *[16]byte, context, key []byte) []byte {
+	ret, out := sliceForAppend(dst, len(prefix)+len(msg)+HeaderSize)
+	body := out[len(prefix):]
+	copy(body[HeaderSize:], msg) // copy handles overlapping slices
+	copy(out, prefix)
+	checkEncrypt(body, body[HeaderSize:], context, key)
+	encrypt(body, body[HeaderSize:], ad, id, domain, random, context, key)
+	return ret
+}
+
+func open(dst, ciphertext, ad []byte, id uint64, domain byte, context, key []byte) ([]byte, error) {
+	if len(ciphertext) < HeaderSize {
+		return nil, errDecrypt
+	}
+	ret, out := sliceForAppend(dst, len(ciphertext)-HeaderSize)
+	checkDecrypt(out, ciphertext, context)
+
+	// decrypt does not write to out if the authentication fails.
+	// Therefore out - which may alias the ciphertext - is left
+	// untouched, such that the caller can retry another format.
+	if err := decrypt(out, ciphertext, ad, id, domain, context, key); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// NewAEAD returns a cipher.AEAD implementing the secretbox construction
+// with the given context and key. The nonce of the AEAD is the 8 byte
+// little endian encoding of the msg id. Like the id the nonce does not
+// need to be unique. If additional data is passed to Seal or Open the AEAD
+// uses EncryptAD and DecryptAD, otherwise Encrypt and Decrypt.
+// The context must be 8 and the key 32 bytes long, otherwise this function
+// panics.
+func NewAEAD(context, key []byte) cipher.AEAD {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	c := new(aead)
+	copy(c.context[:], context)
+	copy(c.key[:], key)
+	return c
+}
+
+type aead struct {
+	context [8]byte
+	key     [KeySize]byte
+}
+
+func (c *aead) NonceSize() int { return 8 }
+
+func (c *aead) Overhead() int { return HeaderSize }
+
+func (c *aead) Seal(dst, nonce, plaintext, additionalData []byte) []byte {
+	if n := len(nonce); n != c.NonceSize() {
+		panic("hydrogen/secretbox: invalid nonce size " + strconv.Itoa(n))
+	}
+	domain := domainBox
+	if len(additionalData) > 0 {
+		domain = domainAD
+	}
+	var random [16]byte
+	readRandom(&random, nil)
+	return seal(dst, plaintext, additionalData, binary.LittleEndian.Uint64(nonce), domain, &random, c.context[:], c.key[:])
+}
+
+func (c *aead) Open(dst, nonce, ciphertext, additionalData []byte) ([]byte, error) {
+	if n := len(nonce); n != c.NonceSize() {
+		panic("hydrogen/secretbox: invalid nonce size " + strconv.Itoa(n))
+	}
+	domain := domainBox
+	if len(additionalData) > 0 {
+		domain = domainAD
+	}
+	return open(dst, ciphertext, additionalData, binary.LittleEndian.Uint64(nonce), domain, c.context[:], c.key[:])
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
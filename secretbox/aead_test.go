@@ -0,0 +1,77 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestSealOpen(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("b634b3278d800dc126f589ef84d82ab04e0a11bc79c5181e195ddf8f376aad8d")
+	for i := 0; i < 200; i += 13 {
+		msg := bytes.Repeat([]byte{byte(i)}, i)
+
+		c := Seal([]byte("prefix"), msg, uint64(i), context, key)
+		if len(c) != len("prefix")+len(msg)+HeaderSize || string(c[:6]) != "prefix" {
+			t.Fatalf("%d: Seal did not append the ciphertext to dst", i)
+		}
+		p, err := Open(nil, c[6:], uint64(i), context, key)
+		if err != nil || !bytes.Equal(p, msg) {
+			t.Fatalf("%d: Open failed: %v", i, err)
+		}
+		if _, err = Open(nil, c[6:], uint64(i+1), context, key); err == nil {
+			t.Fatalf("%d: Open accepted wrong msg id", i)
+		}
+
+		// Reuse the storage of msg / ciphertext.
+		buf := make([]byte, len(msg), len(msg)+HeaderSize)
+		copy(buf, msg)
+		c = Seal(buf[:0], buf, uint64(i), context, key)
+		if p, err = Open(c[:0], c, uint64(i), context, key); err != nil || !bytes.Equal(p, msg) {
+			t.Fatalf("%d: in-place Seal / Open failed: %v", i, err)
+		}
+	}
+}
+
+func TestAEAD(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	c := NewAEAD(context, key)
+
+	var nonce [8]byte
+	binary.LittleEndian.PutUint64(nonce[:], 42)
+	msg := []byte("Hello World")
+	ciphertext := c.Seal(nil, nonce[:], msg, nil)
+	if len(ciphertext) != len(msg)+c.Overhead() {
+		t.Fatalf("got ciphertext of %d bytes - want %d", len(ciphertext), len(msg)+c.Overhead())
+	}
+
+	plaintext := make([]byte, len(msg))
+	if err := Decrypt(plaintext, ciphertext, 42, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("Decrypt failed to decrypt AEAD ciphertext: %v", err)
+	}
+	if plaintext, err := c.Open(nil, nonce[:], ciphertext, nil); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("Open failed: %v", err)
+	}
+	nonce[0]++
+	if _, err := c.Open(nil, nonce[:], ciphertext, nil); err == nil {
+		t.Fatal("Open accepted wrong nonce")
+	}
+
+	ad := []byte("header")
+	ciphertext = c.Seal(nil, nonce[:], msg, ad)
+	if err := DecryptAD(plaintext, ciphertext, ad, 43, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("DecryptAD failed to decrypt AEAD ciphertext: %v", err)
+	}
+	if _, err := c.Open(nil, nonce[:], ciphertext, nil); err == nil {
+		t.Fatal("Open accepted missing additional data")
+	}
+	if _, err := c.Open(nil, nonce[:], ciphertext, []byte("Header")); err == nil {
+		t.Fatal("Open accepted wrong additional data")
+	}
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	crand "crypto/rand"
+	"io"
+	"strconv"
+)
+
+// batchSize is the number of messages processed by one
+// goroutine at once.
+const batchSize = 64
+
+// The batch functions do not share any cryptographic setup between messages.
+// The ChaCha12 subkeys are derived from the msg id, the SipHash context mixing
+// depends on these subkeys and the HChaCha12 input depends on the nonce of the
+// message. Therefore chacha20.Core, auth.New and HChaCha20 must be computed for
+// every message. The batch functions amortize the argument checks, the calls to
+// the RNG and the memory allocations and spread the messages over a bounded
+// pool of goroutines.
+
+// EncryptBatch encrypts and authenticates every msgs[i] with the msg id ids[i]
+// like Encrypt and returns the ciphertexts. The ciphertexts share one underlying
+// buffer and the random data of all messages is read with one call to rand. The
+// messages are encrypted by a bounded pool of goroutines. The reader should return
+// random data or can be nil - than the PRNG of the system will be used.
+// EncryptBatch returns a non-nil error if the reader fails. If msgs and ids differ
+// in length, the context is not 8 or the key not 32 bytes long, this function panics.
+func EncryptBatch(msgs [][]byte, ids []uint64, rand io.Reader, context, key []byte) ([][]byte, error) {
+	if len(msgs) != len(ids) {
+		panic("hydrogen/secretbox: number of msgs and ids does not match")
+	}
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	if rand == nil {
+		rand = crand.Reader // use global RNG
+	}
+
+	random := make([]byte, 16*len(msgs))
+	if _, err := io.ReadFull(rand, random); err != nil {
+		return nil, err
+	}
+
+	size := 0
+	for _, msg := range msgs {
+		size += len(msg) + HeaderSize
+	}
+	buf := make([]byte, size)
+	ciphertexts := make([][]byte, len(msgs))
+	for i, msg := range msgs {
+		n := len(msg) + HeaderSize
+		ciphertexts[i], buf = buf[:n:n], buf[n:]
+	}
+
+	forEachBatch(len(msgs), func(i int) {
+		var r [16]byte
+		copy(r[:], random[16*i:])
+		encrypt(ciphertexts[i], msgs[i], nil, ids[i], domainBox, &r, context, key)
+	})
+	return ciphertexts, nil
+}
+
+// DecryptBatch decrypts and verifies every ciphertexts[i] with the msg id ids[i]
+// like Decrypt and returns the messages and one error per ciphertext. If errs[i]
+// is not nil, the ciphertext could not be decrypted and msgs[i] is nil. The messages
+// share one underlying buffer and are decrypted by a bounded pool of goroutines.
+// If ciphertexts and ids differ in length, the context is not 8 or the key not 32
+// bytes long, this function panics.
+func DecryptBatch(ciphertexts [][]byte, ids []uint64, context, key []byte) (msgs [][]byte, errs []error) {
+	if len(ciphertexts) != len(ids) {
+		panic("hydrogen/secretbox: number of ciphertexts and ids does not match")
+	}
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+
+	size := 0
+	for _, c := range ciphertexts {
+		if len(c) >= HeaderSize {
+			size += len(c) - HeaderSize
+		}
+	}
+	buf := make([]byte, size)
+	msgs = make([][]byte, len(ciphertexts))
+	for i, c := range ciphertexts {
+		if len(c) >= HeaderSize {
+			n := len(c) - HeaderSize
+			msgs[i], buf = buf[:n:n], buf[n:]
+		}
+	}
+
+	errs = make([]error, len(ciphertexts))
+	forEachBatch(len(ciphertexts), func(i int) {
+		if len(ciphertexts[i]) < HeaderSize {
+			errs[i] = errDecrypt
+			return
+		}
+		if errs[i] = decrypt(msgs[i], ciphertexts[i], nil, ids[i], domainBox, context, key); errs[i] != nil {
+			for j := range msgs[i] {
+				msgs[i][j] = 0
+			}
+			msgs[i] = nil
+		}
+	})
+	return msgs, errs
+}
+
+// forEachBatch calls fn for every i in [0, n). The calls are grouped
+// into batches of batchSize processed by a bounded pool of goroutines.
+func forEachBatch(n int, fn func(i int)) {
+	parallel((n+batchSize-1)/batchSize, func(b int) {
+		end := (b + 1) * batchSize
+		if end > n {
+			end = n
+		}
+		for i := b * batchSize; i < end; i++ {
+			fn(i)
+		}
+	})
+}
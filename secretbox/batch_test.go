@@ -0,0 +1,108 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestBatch(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+
+	n := 3*batchSize + 5
+	msgs, ids := make([][]byte, n), make([]uint64, n)
+	for i := range msgs {
+		msgs[i] = bytes.Repeat([]byte{byte(i)}, i%300)
+		ids[i] = uint64(i)
+	}
+	ciphertexts, err := EncryptBatch(msgs, ids, nil, context, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, c := range ciphertexts {
+		msg := make([]byte, len(msgs[i]))
+		if err := Decrypt(msg, c, ids[i], context, key); err != nil || !bytes.Equal(msg, msgs[i]) {
+			t.Fatalf("%d: Decrypt failed to decrypt batch ciphertext: %v", i, err)
+		}
+	}
+
+	ciphertexts[7][HeaderSize-1] ^= 1
+	ciphertexts[11] = ciphertexts[11][:HeaderSize-1]
+	ids[42]++
+	plaintexts, errs := DecryptBatch(ciphertexts, ids, context, key)
+	for i := range ciphertexts {
+		switch i {
+		case 7, 11, 42:
+			if errs[i] == nil || plaintexts[i] != nil {
+				t.Fatalf("%d: DecryptBatch accepted invalid ciphertext", i)
+			}
+		default:
+			if errs[i] != nil || !bytes.Equal(plaintexts[i], msgs[i]) {
+				t.Fatalf("%d: DecryptBatch failed: %v", i, errs[i])
+			}
+		}
+	}
+
+	if _, err = EncryptBatch(msgs, ids, bytes.NewReader(make([]byte, 16)), context, key); err == nil {
+		t.Fatal("EncryptBatch ignored failing reader")
+	}
+}
+
+func TestDecryptBatchInvalidKey(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	defer func() {
+		if recover() == nil {
+			t.Fatal("DecryptBatch accepted invalid key")
+		}
+	}()
+
+	ciphertexts := make([][]byte, 2*batchSize)
+	for i := range ciphertexts {
+		ciphertexts[i] = make([]byte, HeaderSize)
+	}
+	DecryptBatch(ciphertexts, make([]uint64, len(ciphertexts)), []byte("libtests"), make([]byte, 16))
+}
+
+func benchEncryptBatch(size int, b *testing.B) {
+	key := make([]byte, KeySize)
+	context := make([]byte, 8)
+	msgs, ids := make([][]byte, 1000), make([]uint64, 1000)
+	for i := range msgs {
+		msgs[i] = make([]byte, size)
+		ids[i] = uint64(i)
+	}
+
+	b.SetBytes(int64(len(msgs) * size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncryptBatch(msgs, ids, nil, context, key)
+	}
+}
+
+func BenchmarkEncryptBatch200(b *testing.B) { benchEncryptBatch(200, b) }
+
+func benchDecryptBatch(size int, b *testing.B) {
+	key := make([]byte, KeySize)
+	context := make([]byte, 8)
+	msgs, ids := make([][]byte, 1000), make([]uint64, 1000)
+	for i := range msgs {
+		msgs[i] = make([]byte, size)
+		ids[i] = uint64(i)
+	}
+	ciphertexts, _ := EncryptBatch(msgs, ids, nil, context, key)
+
+	b.SetBytes(int64(len(msgs) * size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecryptBatch(ciphertexts, ids, context, key)
+	}
+}
+
+func BenchmarkDecryptBatch200(b *testing.B) { benchDecryptBatch(200, b) }
@@ -0,0 +1,208 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/aead/hydrogen/subtle"
+)
+
+// RandPolicy specifies how encryption handles a failing or short
+// random source.
+type RandPolicy int
+
+const (
+	// FailClosed aborts the encryption and returns ErrRandomness.
+	FailClosed RandPolicy = iota
+	// SyntheticNonce falls back to a synthetic nonce derived from the
+	// SipHash of the msg and a process-wide counter.
+	SyntheticNonce
+	// Retry reads again from the random source up to MaxRetries times
+	// and aborts the encryption if all attempts fail.
+	Retry
+)
+
+// DefaultMaxRetries is the number of retries used by the Retry policy
+// if Config.MaxRetries is not positive.
+const DefaultMaxRetries = 3
+
+// ErrRandomness is returned if encryption is aborted because the
+// random source failed.
+var ErrRandomness = errors.New("hydrogen/secretbox: failed to read random data")
+
+// Config configures the encryption of messages. A nil *Config or the
+// zero value uses the PRNG of the system and the FailClosed policy.
+type Config struct {
+	// Rand is the random source. If nil the PRNG of the system is used.
+	Rand io.Reader
+
+	// Policy specifies the behavior if Rand fails.
+	Policy RandPolicy
+
+	// MaxRetries is the number of retries of the Retry policy.
+	// If not positive DefaultMaxRetries is used.
+	MaxRetries int
+
+	// OnRandError, if not nil, is called with every error returned
+	// by Rand - for example to report hosts with broken entropy.
+	OnRandError func(error)
+
+	// PadBlockSize, if positive, makes Seal pad messages to the next
+	// multiple of PadBlockSize before encryption (see subtle.Pad) and
+	// Open remove the padding after decryption. Padding hides the
+	// exact length of messages.
+	PadBlockSize int
+
+	// PadPowerOfTwo makes Seal pad messages to the next power of two,
+	// but at least to PadBlockSize bytes. Open removes the padding.
+	PadPowerOfTwo bool
+}
+
+// Encrypt works like the Encrypt function but applies the policy of the config
+// if the random source fails. If the encryption is aborted Encrypt returns
+// ErrRandomness and the ciphertext must not be used. The ciphertext must be at
+// least 36 bytes longer than the msg, the context must be 8 and the key 32 bytes
+// long, otherwise this function panics.
+func (c *Config) Encrypt(ciphertext, msg []byte, id uint64, context, key []byte) error {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte
+	if err := c.readRandom(&random); err != nil {
+		return err
+	}
+	encrypt(ciphertext, msg, nil, id, domainBox, &random, context, key)
+	return nil
+}
+
+// EncryptAD works like the EncryptAD function but applies the policy of the
+// config if the random source fails. If the encryption is aborted EncryptAD
+// returns ErrRandomness and the ciphertext must not be used.
+func (c *Config) EncryptAD(ciphertext, msg, ad []byte, id uint64, context, key []byte) error {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte
+	if err := c.readRandom(&random); err != nil {
+		return err
+	}
+	encrypt(ciphertext, msg, ad, id, domainAD, &random, context, key)
+	return nil
+}
+
+// Seal works like the Seal function but applies the policy of the config if the
+// random source fails. If the encryption is aborted Seal returns ErrRandomness.
+// If padding is configured Seal pads the msg before encryption and the ciphertext
+// must be decrypted with Config.Open.
+func (c *Config) Seal(dst, msg []byte, id uint64, context, key []byte) ([]byte, error) {
+	var random [16]byte
+	if err := c.readRandom(&random); err != nil {
+		return nil, err
+	}
+	if blockSize := c.padBlockSize(len(msg)); blockSize > 0 {
+		msg = subtle.Pad(make([]byte, 0, len(msg)+blockSize), msg, blockSize)
+	}
+	return seal(dst, msg, nil, id, domainBox, &random, context, key), nil
+}
+
+// Open works like the Open function but removes the padding of the msg if padding
+// is configured. The config must use the same padding options as the one used by
+// Seal. Open returns a non-nil error if the ciphertext could not be decrypted or
+// the msg is not correctly padded.
+func (c *Config) Open(dst, ciphertext []byte, id uint64, context, key []byte) ([]byte, error) {
+	ret, err := open(dst, ciphertext, nil, id, domainBox, context, key)
+	if err != nil || c.padBlockSize(0) == 0 {
+		return ret, err
+	}
+
+	padded := ret[len(dst):]
+	if len(padded) == 0 {
+		return nil, errDecrypt
+	}
+	blockSize := c.PadBlockSize
+	if c.PadPowerOfTwo {
+		blockSize = len(padded) // the msg is padded to the full length
+	}
+	msg, err := subtle.Unpad(padded, blockSize)
+	if err != nil {
+		for i := range padded {
+			padded[i] = 0
+		}
+		return nil, err
+	}
+	for i := len(msg); i < len(padded); i++ {
+		padded[i] = 0
+	}
+	return ret[:len(dst)+len(msg)], nil
+}
+
+// padBlockSize returns the block size used to pad a msg of n bytes
+// or 0 if no padding is configured.
+func (c *Config) padBlockSize(n int) int {
+	if c == nil {
+		return 0
+	}
+	if !c.PadPowerOfTwo {
+		if c.PadBlockSize > 0 {
+			return c.PadBlockSize
+		}
+		return 0
+	}
+	size := 1
+	for size <= n {
+		size <<= 1
+	}
+	if size < c.PadBlockSize {
+		size = c.PadBlockSize
+	}
+	return size
+}
+
+// syntheticCounter is incremented for every synthetic nonce.
+var syntheticCounter uint64
+
+func (c *Config) readRandom(random *[16]byte) error {
+	var rand io.Reader = crand.Reader
+	policy, retries := FailClosed, 0
+	if c != nil {
+		if c.Rand != nil {
+			rand = c.Rand
+		}
+		policy = c.Policy
+		if policy == Retry {
+			retries = c.MaxRetries
+			if retries <= 0 {
+				retries = DefaultMaxRetries
+			}
+		}
+	}
+
+	for i := 0; ; i++ {
+		_, err := io.ReadFull(rand, random[:])
+		if err == nil {
+			return nil
+		}
+		if c != nil && c.OnRandError != nil {
+			c.OnRandError(err)
+		}
+		if i >= retries {
+			break
+		}
+	}
+
+	if policy == SyntheticNonce {
+		// The nonce is derived from SipHash(msg) and the random part.
+		// Without random data a counter and the time ensure that two
+		// different nonces are computed for the same msg.
+		binary.LittleEndian.PutUint64(random[:8], atomic.AddUint64(&syntheticCounter, 1))
+		binary.LittleEndian.PutUint64(random[8:], uint64(time.Now().UnixNano()))
+		return nil
+	}
+	return ErrRandomness
+}
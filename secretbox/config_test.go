@@ -0,0 +1,139 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+var errBrokenRand = errors.New("broken rand")
+
+// failingReader fails the first n reads.
+type failingReader struct{ n int }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.n > 0 {
+		r.n--
+		return 0, errBrokenRand
+	}
+	for i := range p {
+		p[i] = 0x42
+	}
+	return len(p), nil
+}
+
+func TestConfigPolicy(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("Hello World")
+	ciphertext := make([]byte, len(msg)+HeaderSize)
+	plaintext := make([]byte, len(msg))
+
+	var errs int
+	hook := func(err error) {
+		if err != errBrokenRand && err != io.ErrUnexpectedEOF {
+			t.Fatalf("hook received unexpected error: %v", err)
+		}
+		errs++
+	}
+
+	c := &Config{Rand: &failingReader{n: 1}, OnRandError: hook}
+	if err := c.Encrypt(ciphertext, msg, 0, context, key); err != ErrRandomness {
+		t.Fatalf("FailClosed: got %v - want %v", err, ErrRandomness)
+	}
+	if errs != 1 {
+		t.Fatalf("FailClosed: hook called %d times - want 1", errs)
+	}
+
+	errs = 0
+	c = &Config{Rand: &failingReader{n: 2}, Policy: Retry, MaxRetries: 2, OnRandError: hook}
+	if err := c.Encrypt(ciphertext, msg, 0, context, key); err != nil {
+		t.Fatalf("Retry: %v", err)
+	}
+	if errs != 2 {
+		t.Fatalf("Retry: hook called %d times - want 2", errs)
+	}
+	if err := Decrypt(plaintext, ciphertext, 0, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("Retry: Decrypt failed: %v", err)
+	}
+	c.Rand = &failingReader{n: 3}
+	if err := c.Encrypt(ciphertext, msg, 0, context, key); err != ErrRandomness {
+		t.Fatalf("Retry: got %v - want %v", err, ErrRandomness)
+	}
+
+	c = &Config{Rand: bytes.NewReader(make([]byte, 8)), Policy: SyntheticNonce}
+	if err := c.Encrypt(ciphertext, msg, 0, context, key); err != nil {
+		t.Fatalf("SyntheticNonce: %v", err)
+	}
+	if err := Decrypt(plaintext, ciphertext, 0, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("SyntheticNonce: Decrypt failed: %v", err)
+	}
+	ciphertext2, err := c.Seal(nil, msg, 0, context, key)
+	if err != nil {
+		t.Fatalf("SyntheticNonce: %v", err)
+	}
+	if bytes.Equal(ciphertext, ciphertext2) {
+		t.Fatal("SyntheticNonce: equal nonces for two encryptions")
+	}
+}
+
+func TestNilConfig(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("Hello World")
+
+	var c *Config
+	ciphertext, err := c.Seal(nil, msg, 1, context, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plaintext, err := Open(nil, ciphertext, 1, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("Open failed: %v", err)
+	}
+}
+
+func TestConfigPadding(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+
+	configs := []*Config{
+		{PadBlockSize: 16},
+		{PadPowerOfTwo: true},
+		{PadPowerOfTwo: true, PadBlockSize: 64},
+	}
+	sizes := [][]int{ // msg length -> padded length
+		{0, 16, 15, 16, 16, 32, 100, 112},
+		{0, 1, 1, 2, 3, 4, 100, 128},
+		{0, 64, 63, 64, 64, 128, 100, 128},
+	}
+	for i, c := range configs {
+		for j := 0; j < len(sizes[i]); j += 2 {
+			msg := bytes.Repeat([]byte{0x80}, sizes[i][j])
+			ciphertext, err := c.Seal(nil, msg, 1, context, key)
+			if err != nil {
+				t.Fatalf("%d: Seal failed: %v", i, err)
+			}
+			if n := len(ciphertext) - HeaderSize; n != sizes[i][j+1] {
+				t.Fatalf("%d: msg of %d bytes padded to %d bytes - want %d", i, len(msg), n, sizes[i][j+1])
+			}
+			plaintext, err := c.Open([]byte("prefix"), ciphertext, 1, context, key)
+			if err != nil {
+				t.Fatalf("%d: Open failed: %v", i, err)
+			}
+			if string(plaintext[:6]) != "prefix" || !bytes.Equal(plaintext[6:], msg) {
+				t.Fatalf("%d: Open returned unexpected msg", i)
+			}
+		}
+	}
+
+	// An unpadded msg is rejected.
+	ciphertext := Seal(nil, []byte{1, 2, 3, 4}, 1, context, key)
+	if _, err := configs[0].Open(nil, ciphertext, 1, context, key); err == nil {
+		t.Fatal("Open accepted msg without padding")
+	}
+}
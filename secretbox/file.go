@@ -0,0 +1,367 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+
+	"github.com/aead/hydrogen/internal/chacha20"
+)
+
+const (
+	// FileBlockSize is the size of one plaintext block of a File in bytes.
+	FileBlockSize = 4096
+	// FileHeaderSize is the size of the header of a File in bytes.
+	FileHeaderSize = 8 + 16 + 8 + HeaderSize
+
+	fileMagic   = "hydrofs1"
+	fileBlock   = FileBlockSize + HeaderSize
+	fileSizeID  = 1<<64 - 1 // msg id of the encrypted file size
+	maxFileSize = 1<<63 - 1
+)
+
+var (
+	errNotAFile    = errors.New("hydrogen/secretbox: file has no valid header")
+	errNegativeOff = errors.New("hydrogen/secretbox: negative offset")
+)
+
+// File is an encrypted file supporting random access.
+//
+// The plaintext is split into blocks of FileBlockSize bytes. Every block
+// is encrypted independently with Encrypt using its index as msg id, such
+// that any range of the file can be read and modified without processing
+// the entire file. The header of the file contains a random file id, used
+// to derive a file specific key, and the authenticated size of the file.
+// The last block is encrypted with EncryptAD using the file size as additional
+// data. Therefore a File detects modified, swapped and truncated blocks, blocks
+// copied from other files and a header replaced with an older version. It cannot
+// detect that a block has been replaced with an older version of the same block -
+// and therefore neither a rollback of the header together with the last block.
+//
+// A File is safe for concurrent use. Writes are not atomic - if a write
+// fails the File must not be used anymore.
+type File struct {
+	mu      sync.Mutex
+	file    *os.File
+	context []byte
+	key     [KeySize]byte
+	size    int64
+	offset  int64
+
+	block      [FileBlockSize]byte
+	ciphertext [fileBlock]byte
+}
+
+// CreateFile initializes an empty encrypted file and returns a File for it.
+// All existing content of f is discarded. The context must be 8 and the key
+// 32 bytes long, otherwise this function panics.
+func CreateFile(f *os.File, context, key []byte) (*File, error) {
+	var fileID [16]byte
+	if _, err := io.ReadFull(crand.Reader, fileID[:]); err != nil {
+		return nil, err
+	}
+	file := newFile(f, fileID[:], context, key)
+	if err := f.Truncate(0); err != nil {
+		return nil, err
+	}
+	header := make([]byte, 8+16)
+	copy(header, fileMagic)
+	copy(header[8:], fileID[:])
+	if _, err := f.WriteAt(header, 0); err != nil {
+		return nil, err
+	}
+	if err := file.writeSize(0); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+// OpenFile returns a File for the encrypted file f created by CreateFile.
+// It returns a non-nil error if the header of f cannot be authenticated.
+// The context must be 8 and the key 32 bytes long, otherwise this function
+// panics.
+func OpenFile(f *os.File, context, key []byte) (*File, error) {
+	var header [FileHeaderSize]byte
+	if _, err := f.ReadAt(header[:], 0); err != nil {
+		if err == io.EOF {
+			err = errNotAFile
+		}
+		return nil, err
+	}
+	if string(header[:8]) != fileMagic {
+		return nil, errNotAFile
+	}
+	file := newFile(f, header[8:24], context, key)
+
+	var size [8]byte
+	if err := Decrypt(size[:], header[24:], fileSizeID, file.context, file.key[:]); err != nil {
+		return nil, err
+	}
+	file.size = int64(binary.LittleEndian.Uint64(size[:]))
+	if file.size < 0 {
+		return nil, errNotAFile
+	}
+
+	// The length of f and the last block must match the size of the header.
+	// Otherwise the header has been replaced by an older version.
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() != fileLength(file.size) {
+		return nil, errDecrypt
+	}
+	if file.size > 0 {
+		if _, err = file.readBlock(lastBlock(file.size)); err != nil {
+			return nil, err
+		}
+	}
+	return file, nil
+}
+
+func newFile(f *os.File, fileID, context, key []byte) *File {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	file := &File{file: f, context: make([]byte, 8)}
+	copy(file.context, context)
+	chacha20.HChaCha20(file.key[:], fileID, key)
+	return file
+}
+
+// Size returns the size of the plaintext in bytes.
+func (f *File) Size() int64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.size
+}
+
+// ReadAt implements io.ReaderAt. It returns a non-nil error
+// if a block of the file cannot be authenticated.
+func (f *File) ReadAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.readAt(p, off)
+}
+
+// WriteAt implements io.WriterAt. Writing beyond the end of the file
+// fills the gap with zeros.
+func (f *File) WriteAt(p []byte, off int64) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.writeAt(p, off)
+}
+
+// Read implements io.Reader.
+func (f *File) Read(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err = f.readAt(p, f.offset)
+	f.offset += int64(n)
+	return
+}
+
+// Write implements io.Writer.
+func (f *File) Write(p []byte) (n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n, err = f.writeAt(p, f.offset)
+	f.offset += int64(n)
+	return
+}
+
+// Seek implements io.Seeker. Offsets are relative to the plaintext.
+func (f *File) Seek(offset int64, whence int) (int64, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch whence {
+	default:
+		return f.offset, errors.New("hydrogen/secretbox: invalid whence " + strconv.Itoa(whence))
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += f.offset
+	case io.SeekEnd:
+		offset += f.size
+	}
+	if offset < 0 {
+		return f.offset, errNegativeOff
+	}
+	f.offset = offset
+	return offset, nil
+}
+
+func (f *File) readAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errNegativeOff
+	}
+	if off >= f.size {
+		return 0, io.EOF
+	}
+	if rem := f.size - off; int64(len(p)) > rem {
+		p = p[:rem]
+		err = io.EOF
+	}
+	for len(p) > 0 {
+		index, pos := off/FileBlockSize, int(off%FileBlockSize)
+		block, rErr := f.readBlock(index)
+		if rErr != nil {
+			return n, rErr
+		}
+		c := copy(p, block[pos:])
+		n += c
+		off += int64(c)
+		p = p[c:]
+	}
+	return
+}
+
+func (f *File) writeAt(p []byte, off int64) (n int, err error) {
+	if off < 0 {
+		return 0, errNegativeOff
+	}
+	if off > maxFileSize-int64(len(p)) {
+		return 0, errors.New("hydrogen/secretbox: file too large")
+	}
+	newSize := f.size
+	if end := off + int64(len(p)); end > newSize {
+		newSize = end
+	}
+	for f.size < off { // fill the gap with zeros
+		var zeros [FileBlockSize]byte
+		gap := zeros[:FileBlockSize-int(f.size%FileBlockSize)]
+		if int64(len(gap)) > off-f.size {
+			gap = gap[:off-f.size]
+		}
+		if _, err = f.writeAt(gap, f.size); err != nil {
+			return 0, err
+		}
+	}
+
+	size := f.size
+	if newSize > f.size && f.size > 0 && off == f.size && f.size%FileBlockSize == 0 {
+		// The current last block is full and becomes an inner block.
+		index := lastBlock(f.size)
+		block, err := f.readBlock(index)
+		if err != nil {
+			return 0, err
+		}
+		if err = f.writeBlock(index, block, newSize); err != nil {
+			return 0, err
+		}
+	}
+	for len(p) > 0 {
+		index, pos := off/FileBlockSize, int(off%FileBlockSize)
+		var block []byte
+		if blockStart := index * FileBlockSize; blockStart < f.size {
+			if block, err = f.readBlock(index); err != nil {
+				return
+			}
+		} else {
+			block = f.block[:0]
+		}
+		c := len(p)
+		if c > FileBlockSize-pos {
+			c = FileBlockSize - pos
+		}
+		if len(block) < pos+c {
+			block = f.block[:pos+c]
+		}
+		copy(block[pos:], p[:c])
+		if err = f.writeBlock(index, block, newSize); err != nil {
+			return
+		}
+		n += c
+		off += int64(c)
+		p = p[c:]
+		if off > size {
+			size = off
+		}
+	}
+	if size > f.size {
+		if err = f.writeSize(size); err != nil {
+			return
+		}
+	}
+	return
+}
+
+// readBlock reads and decrypts the block with the given index. The
+// returned slice aliases f.block and is valid until the next call.
+func (f *File) readBlock(index int64) ([]byte, error) {
+	length := f.size - index*FileBlockSize
+	if length > FileBlockSize {
+		length = FileBlockSize
+	}
+	ciphertext := f.ciphertext[:int(length)+HeaderSize]
+	if _, err := f.file.ReadAt(ciphertext, FileHeaderSize+index*fileBlock); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	block := f.block[:length]
+	var err error
+	if index == lastBlock(f.size) {
+		var size [8]byte
+		binary.LittleEndian.PutUint64(size[:], uint64(f.size))
+		err = DecryptAD(block, ciphertext, size[:], uint64(index), f.context, f.key[:])
+	} else {
+		err = Decrypt(block, ciphertext, uint64(index), f.context, f.key[:])
+	}
+	if err != nil {
+		return nil, err
+	}
+	return block, nil
+}
+
+// writeBlock encrypts and writes the block with the given index. The
+// size is the file size after the write and binds the last block.
+func (f *File) writeBlock(index int64, block []byte, size int64) error {
+	ciphertext := f.ciphertext[:len(block)+HeaderSize]
+	if index == lastBlock(size) {
+		var ad [8]byte
+		binary.LittleEndian.PutUint64(ad[:], uint64(size))
+		EncryptAD(ciphertext, block, ad[:], uint64(index), nil, f.context, f.key[:])
+	} else {
+		Encrypt(ciphertext, block, uint64(index), nil, f.context, f.key[:])
+	}
+	_, err := f.file.WriteAt(ciphertext, FileHeaderSize+index*fileBlock)
+	return err
+}
+
+// lastBlock returns the index of the last block of a non-empty
+// file with the given size.
+func lastBlock(size int64) int64 { return (size - 1) / FileBlockSize }
+
+// fileLength returns the length of an encrypted file with the given
+// plaintext size in bytes.
+func fileLength(size int64) int64 {
+	length := FileHeaderSize + (size/FileBlockSize)*fileBlock
+	if rem := size % FileBlockSize; rem > 0 {
+		length += rem + HeaderSize
+	}
+	return length
+}
+
+func (f *File) writeSize(size int64) error {
+	var length [8]byte
+	var ciphertext [8 + HeaderSize]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(size))
+	Encrypt(ciphertext[:], length[:], fileSizeID, nil, f.context, f.key[:])
+	if _, err := f.file.WriteAt(ciphertext[:], 8+16); err != nil {
+		return err
+	}
+	f.size = size
+	return nil
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func tempFile(t *testing.T) *os.File {
+	f, err := ioutil.TempFile("", "hydrogen")
+	if err != nil {
+		t.Fatalf("Failed to create temp. file: %v", err)
+	}
+	return f
+}
+
+func removeFile(f *os.File) {
+	f.Close()
+	os.Remove(f.Name())
+}
+
+var fileWrites = []struct {
+	off  int64
+	size int
+}{
+	{0, 10},
+	{5, 100},
+	{FileBlockSize - 3, 7},
+	{3*FileBlockSize + 17, 2 * FileBlockSize},
+	{0, 4*FileBlockSize + 1},
+	{2*FileBlockSize - 1, 1},
+	{7 * FileBlockSize, 0},
+}
+
+func TestFile(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	osFile := tempFile(t)
+	defer removeFile(osFile)
+
+	f, err := CreateFile(osFile, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	var plaintext []byte
+	for i, v := range fileWrites {
+		p := bytes.Repeat([]byte{byte(i + 1)}, v.size)
+		if n, err := f.WriteAt(p, v.off); n != len(p) || err != nil {
+			t.Fatalf("%d: WriteAt failed: n = %d err = %v", i, n, err)
+		}
+		if end := v.off + int64(v.size); end > int64(len(plaintext)) {
+			plaintext = append(plaintext, make([]byte, end-int64(len(plaintext)))...)
+		}
+		copy(plaintext[v.off:], p)
+		if f.Size() != int64(len(plaintext)) {
+			t.Fatalf("%d: got size %d - want %d", i, f.Size(), len(plaintext))
+		}
+	}
+
+	f, err = OpenFile(osFile, context, key)
+	if err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+	content, err := ioutil.ReadAll(f)
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	if !bytes.Equal(content, plaintext) {
+		t.Fatal("File content does not match plaintext")
+	}
+
+	buf := make([]byte, 100)
+	for _, off := range []int64{0, FileBlockSize - 50, int64(len(plaintext)) - 100} {
+		if _, err := f.ReadAt(buf, off); err != nil {
+			t.Fatalf("ReadAt(%d) failed: %v", off, err)
+		}
+		if !bytes.Equal(buf, plaintext[off:off+100]) {
+			t.Fatalf("ReadAt(%d) returned wrong data", off)
+		}
+	}
+	if n, err := f.ReadAt(buf, int64(len(plaintext))-10); n != 10 || err != io.EOF {
+		t.Fatalf("ReadAt at end of file: n = %d err = %v", n, err)
+	}
+	if pos, err := f.Seek(-10, io.SeekEnd); pos != int64(len(plaintext))-10 || err != nil {
+		t.Fatalf("Seek failed: pos = %d err = %v", pos, err)
+	}
+	if _, err = OpenFile(osFile, []byte("wrongctx"), key); err == nil {
+		t.Fatal("OpenFile accepted wrong context")
+	}
+}
+
+func TestFileTampering(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	osFile := tempFile(t)
+	defer removeFile(osFile)
+
+	f, err := CreateFile(osFile, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	if _, err = f.Write(make([]byte, 3*FileBlockSize)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	block0, block1 := make([]byte, fileBlock), make([]byte, fileBlock)
+	osFile.ReadAt(block0, FileHeaderSize)
+	osFile.ReadAt(block1, FileHeaderSize+fileBlock)
+
+	// Swap the first two blocks
+	osFile.WriteAt(block1, FileHeaderSize)
+	osFile.WriteAt(block0, FileHeaderSize+fileBlock)
+	if _, err = f.ReadAt(make([]byte, 1), 0); err == nil {
+		t.Fatal("ReadAt accepted swapped block")
+	}
+	osFile.WriteAt(block0, FileHeaderSize)
+	osFile.WriteAt(block1, FileHeaderSize+fileBlock)
+
+	// Truncate the file
+	osFile.Truncate(FileHeaderSize + 2*fileBlock + 100)
+	if _, err = f.ReadAt(make([]byte, 1), 2*FileBlockSize); err == nil {
+		t.Fatal("ReadAt accepted truncated file")
+	}
+
+	// Copy a block from another file with the same key
+	other := tempFile(t)
+	defer removeFile(other)
+	g, err := CreateFile(other, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	g.Write(make([]byte, FileBlockSize))
+	other.ReadAt(block0, FileHeaderSize)
+	osFile.WriteAt(block0, FileHeaderSize)
+	if _, err = f.ReadAt(make([]byte, 1), 0); err == nil {
+		t.Fatal("ReadAt accepted block of another file")
+	}
+}
+
+func TestFileHeaderRollback(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	osFile := tempFile(t)
+	defer removeFile(osFile)
+
+	f, err := CreateFile(osFile, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create file: %v", err)
+	}
+	emptyHeader := make([]byte, FileHeaderSize)
+	osFile.ReadAt(emptyHeader, 0)
+
+	if _, err = f.Write(make([]byte, FileBlockSize)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	oldHeader := make([]byte, FileHeaderSize)
+	osFile.ReadAt(oldHeader, 0)
+
+	if _, err = f.Write(make([]byte, 3*FileBlockSize)); err != nil {
+		t.Fatalf("Failed to write: %v", err)
+	}
+	if _, err = OpenFile(osFile, context, key); err != nil {
+		t.Fatalf("Failed to open file: %v", err)
+	}
+
+	// Replay the header of the 4 KiB file.
+	osFile.WriteAt(oldHeader, 0)
+	if _, err = OpenFile(osFile, context, key); err == nil {
+		t.Fatal("OpenFile accepted replayed header")
+	}
+
+	// Replay the header and truncate the file to the old length.
+	osFile.Truncate(fileLength(FileBlockSize))
+	if _, err = OpenFile(osFile, context, key); err == nil {
+		t.Fatal("OpenFile accepted replayed header of truncated file")
+	}
+
+	// Replay the header of the empty file.
+	osFile.WriteAt(emptyHeader, 0)
+	if _, err = OpenFile(osFile, context, key); err == nil {
+		t.Fatal("OpenFile accepted replayed header of empty file")
+	}
+}
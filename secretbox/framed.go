@@ -0,0 +1,72 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import "errors"
+
+const (
+	// FrameVersion is the current version of the framed ciphertext format.
+	FrameVersion = 1
+	// SuiteXChaCha12SipHash identifies the XChaCha12 / SipHash-128
+	// construction used by Encrypt.
+	SuiteXChaCha12SipHash = 1
+	// FrameHeaderSize is the size of the header of a framed ciphertext in bytes.
+	FrameHeaderSize = 2
+	// FramedOverhead is the overhead of a framed ciphertext in bytes.
+	FramedOverhead = FrameHeaderSize + HeaderSize
+)
+
+var errUnsupportedFrame = errors.New("hydrogen/secretbox: unsupported ciphertext version or suite")
+
+// SealFramed encrypts and authenticates msg like Seal, appends a self-describing
+// ciphertext to dst and returns the updated slice. A framed ciphertext has the form:
+//
+//	version (1 byte) || suite (1 byte) || nonce || mac || enc
+//
+// The version and suite are authenticated as additional data of a dedicated domain,
+// such that framed ciphertexts cannot be confused with EncryptAD ciphertexts. To
+// reuse msg's storage for the ciphertext, use msg[:0] as dst. Framed ciphertexts
+// must be decrypted with OpenFramed or OpenFramedOrRaw. The context must be 8 and
+// the key 32 bytes long, otherwise this function panics.
+func SealFramed(dst, msg []byte, id uint64, context, key []byte) []byte {
+	header := [FrameHeaderSize]byte{FrameVersion, SuiteXChaCha12SipHash}
+
+	var random [16]byte
+	readRandom(&random, nil)
+	return sealPrefixed(dst, header[:], msg, header[:], id, domainFramed, &random, context, key)
+}
+
+// OpenFramed decrypts and verifies a framed ciphertext created by SealFramed,
+// appends the resulting message to dst and returns the updated slice. It selects
+// the construction based on the version and suite of the ciphertext and returns
+// a non-nil error if they are not supported or the ciphertext could not be
+// decrypted. The context must be 8 and the key 32 bytes long, otherwise this
+// function panics.
+func OpenFramed(dst, ciphertext []byte, id uint64, context, key []byte) ([]byte, error) {
+	if len(ciphertext) < FramedOverhead {
+		return nil, errDecrypt
+	}
+	header, body := ciphertext[:FrameHeaderSize], ciphertext[FrameHeaderSize:]
+	switch {
+	case header[0] == FrameVersion && header[1] == SuiteXChaCha12SipHash:
+		return open(dst, body, header, id, domainFramed, context, key)
+	default:
+		return nil, errUnsupportedFrame
+	}
+}
+
+// OpenFramedOrRaw works like OpenFramed but also accepts legacy ciphertexts
+// created by Encrypt or Seal. It should only be used while migrating existing
+// ciphertexts to the framed format.
+func OpenFramedOrRaw(dst, ciphertext []byte, id uint64, context, key []byte) ([]byte, error) {
+	// A raw ciphertext starts with a random nonce which may look like a
+	// supported header. Therefore a failed framed decryption falls back
+	// to the raw format. A failed authentication does not modify dst,
+	// so the fallback also works if dst aliases the ciphertext.
+	if msg, err := OpenFramed(dst, ciphertext, id, context, key); err == nil {
+		return msg, nil
+	}
+	return Open(dst, ciphertext, id, context, key)
+}
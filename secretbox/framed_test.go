@@ -0,0 +1,99 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestFramed(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("Hello World")
+
+	c := SealFramed([]byte("prefix"), msg, 1, context, key)
+	if string(c[:6]) != "prefix" {
+		t.Fatal("SealFramed did not append to dst")
+	}
+	c = c[6:]
+	if len(c) != len(msg)+FramedOverhead || c[0] != FrameVersion || c[1] != SuiteXChaCha12SipHash {
+		t.Fatalf("invalid framed ciphertext: %x", c)
+	}
+	if p, err := OpenFramed(nil, c, 1, context, key); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("OpenFramed failed: %v", err)
+	}
+	if p, err := OpenFramedOrRaw(nil, c, 1, context, key); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("OpenFramedOrRaw failed: %v", err)
+	}
+	if _, err := Open(nil, c[FrameHeaderSize:], 1, context, key); err == nil {
+		t.Fatal("Open accepted framed ciphertext body")
+	}
+
+	// Framed ciphertexts cannot be confused with EncryptAD ciphertexts.
+	body := make([]byte, len(msg))
+	if DecryptAD(body, c[FrameHeaderSize:], c[:FrameHeaderSize], 1, context, key) == nil {
+		t.Fatal("DecryptAD accepted framed ciphertext")
+	}
+	ad := []byte{FrameVersion, SuiteXChaCha12SipHash}
+	forged := make([]byte, len(msg)+FramedOverhead)
+	copy(forged, ad)
+	EncryptAD(forged[FrameHeaderSize:], msg, ad, 1, nil, context, key)
+	if _, err := OpenFramed(nil, forged, 1, context, key); err == nil {
+		t.Fatal("OpenFramed accepted EncryptAD ciphertext")
+	}
+
+	// Reuse the storage of msg.
+	buf := make([]byte, len(msg), len(msg)+FramedOverhead)
+	copy(buf, msg)
+	inPlace := SealFramed(buf[:0], buf, 1, context, key)
+	if p, err := OpenFramed(nil, inPlace, 1, context, key); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("in-place SealFramed failed: got %q: %v", p, err)
+	}
+
+	c[1] = 2
+	if _, err := OpenFramed(nil, c, 1, context, key); err != errUnsupportedFrame {
+		t.Fatalf("got %v - want %v", err, errUnsupportedFrame)
+	}
+	c[0], c[1] = 2, SuiteXChaCha12SipHash
+	if _, err := OpenFramed(nil, c, 1, context, key); err != errUnsupportedFrame {
+		t.Fatalf("got %v - want %v", err, errUnsupportedFrame)
+	}
+
+	raw := Seal(nil, msg, 1, context, key)
+	if _, err := OpenFramed(nil, raw, 1, context, key); err == nil {
+		t.Fatal("OpenFramed accepted raw ciphertext")
+	}
+	if p, err := OpenFramedOrRaw(nil, raw, 1, context, key); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("OpenFramedOrRaw failed to decrypt raw ciphertext: %v", err)
+	}
+	raw[len(raw)-1] ^= 1
+	if _, err := OpenFramedOrRaw(nil, raw, 1, context, key); err == nil {
+		t.Fatal("OpenFramedOrRaw accepted modified raw ciphertext")
+	}
+}
+
+func TestOpenFramedOrRawInPlace(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("Hello World")
+
+	// Find a raw ciphertext which starts like a framed one.
+	var random [16]byte
+	ciphertext := make([]byte, len(msg)+HeaderSize)
+	for i := uint64(0); ; i++ {
+		binary.LittleEndian.PutUint64(random[:], i)
+		Encrypt(ciphertext, msg, 1, bytes.NewReader(random[:]), context, key)
+		if ciphertext[0] == FrameVersion && ciphertext[1] == SuiteXChaCha12SipHash {
+			break
+		}
+	}
+
+	plaintext, err := OpenFramedOrRaw(ciphertext[:0], ciphertext, 1, context, key)
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("in-place OpenFramedOrRaw failed: %v", err)
+	}
+}
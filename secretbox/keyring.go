@@ -0,0 +1,181 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"strconv"
+	"sync"
+)
+
+const (
+	// KeyIDSize is the size of the key id prefix of keyring ciphertexts in bytes.
+	KeyIDSize = 4
+	// KeyringOverhead is the overhead of keyring ciphertexts in bytes.
+	KeyringOverhead = KeyIDSize + HeaderSize
+)
+
+var (
+	// ErrUnknownKey is returned if a keyring does not contain a key
+	// with the requested key id.
+	ErrUnknownKey = errors.New("hydrogen/secretbox: unknown key id")
+	// ErrNoActiveKey is returned if a keyring without active key
+	// is used for encryption.
+	ErrNoActiveKey = errors.New("hydrogen/secretbox: no active key")
+	// ErrDuplicateKey is returned if a key id is added twice to a keyring.
+	ErrDuplicateKey = errors.New("hydrogen/secretbox: key id already exists")
+	// ErrRetireActiveKey is returned if the active key of a keyring
+	// should be retired.
+	ErrRetireActiveKey = errors.New("hydrogen/secretbox: cannot retire the active key")
+)
+
+// Keyring is a set of keys identified by a 32 bit key id. It encrypts
+// with the active key and decrypts with the key matching the key id of
+// the ciphertext. Therefore keys can be rotated without re-encrypting all
+// ciphertexts at once.
+//
+// A keyring ciphertext has the form:
+//
+//	key_id (4 byte, little endian) || nonce || mac || enc
+//
+// The key id is authenticated as additional data. Keyring ciphertexts use
+// their own domain and cannot be decrypted by DecryptAD.
+// It is safe to use a Keyring from multiple goroutines.
+type Keyring struct {
+	context [8]byte
+
+	lock      sync.RWMutex
+	keys      map[uint32]*[KeySize]byte
+	active    uint32
+	hasActive bool
+}
+
+// NewKeyring returns a new, empty keyring using the given context.
+// The context must be 8 bytes long, otherwise this function panics.
+func NewKeyring(context []byte) *Keyring {
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	k := &Keyring{keys: make(map[uint32]*[KeySize]byte)}
+	copy(k.context[:], context)
+	return k
+}
+
+// Add adds the key with the given key id to the keyring. The first key
+// added to the keyring becomes the active key. Add returns ErrDuplicateKey
+// if the keyring already contains a key with the same id. The key must be
+// 32 bytes long, otherwise this function panics.
+func (k *Keyring) Add(keyID uint32, key []byte) error {
+	if n := len(key); n != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(n))
+	}
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if _, ok := k.keys[keyID]; ok {
+		return ErrDuplicateKey
+	}
+	entry := new([KeySize]byte)
+	copy(entry[:], key)
+	k.keys[keyID] = entry
+	if !k.hasActive {
+		k.active, k.hasActive = keyID, true
+	}
+	return nil
+}
+
+// SetActive makes the key with the given key id the active key which
+// is used for encryption. It returns ErrUnknownKey if the keyring does
+// not contain such a key.
+func (k *Keyring) SetActive(keyID uint32) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	if _, ok := k.keys[keyID]; !ok {
+		return ErrUnknownKey
+	}
+	k.active, k.hasActive = keyID, true
+	return nil
+}
+
+// Active returns the id of the active key. The boolean is false
+// if the keyring has no active key.
+func (k *Keyring) Active() (keyID uint32, ok bool) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+	return k.active, k.hasActive
+}
+
+// Retire removes the key with the given key id from the keyring.
+// Ciphertexts created with this key cannot be decrypted anymore.
+// Retire returns ErrUnknownKey if there is no such key and
+// ErrRetireActiveKey if the key is the active key.
+func (k *Keyring) Retire(keyID uint32) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+
+	entry, ok := k.keys[keyID]
+	if !ok {
+		return ErrUnknownKey
+	}
+	if k.hasActive && k.active == keyID {
+		return ErrRetireActiveKey
+	}
+	for i := range entry {
+		entry[i] = 0
+	}
+	delete(k.keys, keyID)
+	return nil
+}
+
+// Seal encrypts and authenticates msg with the active key, appends the
+// key id and the ciphertext to dst and returns the updated slice.
+// To reuse msg's storage for the ciphertext, use msg[:0] as dst.
+// Seal returns ErrNoActiveKey if the keyring has no active key.
+func (k *Keyring) Seal(dst, msg []byte, id uint64) ([]byte, error) {
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	if !k.hasActive {
+		return nil, ErrNoActiveKey
+	}
+	var keyID [KeyIDSize]byte
+	binary.LittleEndian.PutUint32(keyID[:], k.active)
+
+	var random [16]byte
+	readRandom(&random, nil)
+	return sealPrefixed(dst, keyID[:], msg, keyID[:], id, domainKeyring, &random, k.context[:], k.keys[k.active][:]), nil
+}
+
+// Open decrypts and verifies a ciphertext created by Seal with the key
+// matching its key id, appends the message to dst and returns the updated
+// slice. Open returns ErrUnknownKey if the keyring does not contain the key
+// and a non-nil error if the ciphertext could not be decrypted.
+func (k *Keyring) Open(dst, ciphertext []byte, id uint64) ([]byte, error) {
+	keyID, err := KeyID(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+
+	k.lock.RLock()
+	defer k.lock.RUnlock()
+
+	key, ok := k.keys[keyID]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+	return open(dst, ciphertext[KeyIDSize:], ciphertext[:KeyIDSize], id, domainKeyring, k.context[:], key[:])
+}
+
+// KeyID returns the id of the key used to create the given keyring
+// ciphertext. It returns a non-nil error if the ciphertext is too short.
+// The key id is not authenticated until the ciphertext is decrypted.
+func KeyID(ciphertext []byte) (uint32, error) {
+	if len(ciphertext) < KeyringOverhead {
+		return 0, errDecrypt
+	}
+	return binary.LittleEndian.Uint32(ciphertext), nil
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	key1 := bytes.Repeat([]byte{1}, KeySize)
+	key2 := bytes.Repeat([]byte{2}, KeySize)
+	msg := []byte("Hello World")
+
+	k := NewKeyring([]byte("libtests"))
+	if _, err := k.Seal(nil, msg, 0); err != ErrNoActiveKey {
+		t.Fatalf("got %v - want %v", err, ErrNoActiveKey)
+	}
+	if err := k.Add(1, key1); err != nil {
+		t.Fatal(err)
+	}
+	if err := k.Add(1, key2); err != ErrDuplicateKey {
+		t.Fatalf("got %v - want %v", err, ErrDuplicateKey)
+	}
+	if id, ok := k.Active(); !ok || id != 1 {
+		t.Fatalf("first key is not active: got %d", id)
+	}
+
+	c1, err := k.Seal(nil, msg, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c1) != len(msg)+KeyringOverhead {
+		t.Fatalf("got ciphertext of %d bytes - want %d", len(c1), len(msg)+KeyringOverhead)
+	}
+
+	if err = k.Add(2, key2); err != nil {
+		t.Fatal(err)
+	}
+	if err = k.SetActive(3); err != ErrUnknownKey {
+		t.Fatalf("got %v - want %v", err, ErrUnknownKey)
+	}
+	if err = k.SetActive(2); err != nil {
+		t.Fatal(err)
+	}
+	c2, err := k.Seal(nil, msg, 7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := KeyID(c1); id != 1 {
+		t.Fatalf("KeyID: got %d - want 1", id)
+	}
+	if id, _ := KeyID(c2); id != 2 {
+		t.Fatalf("KeyID: got %d - want 2", id)
+	}
+	for i, c := range [][]byte{c1, c2} {
+		if p, err := k.Open(nil, c, 7); err != nil || !bytes.Equal(p, msg) {
+			t.Fatalf("%d: Open failed: %v", i, err)
+		}
+	}
+
+	// Keyring ciphertexts use their own domain.
+	body := make([]byte, len(c1)-KeyringOverhead)
+	if DecryptAD(body, c1[KeyIDSize:], c1[:KeyIDSize], 7, []byte("libtests"), key1) == nil {
+		t.Fatal("DecryptAD accepted keyring ciphertext")
+	}
+
+	// Reuse the storage of msg.
+	buf := make([]byte, len(msg), len(msg)+KeyringOverhead)
+	copy(buf, msg)
+	c3, err := k.Seal(buf[:0], buf, 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p, err := k.Open(nil, c3, 9); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("in-place Seal failed: got %q: %v", p, err)
+	}
+
+	// The key id is authenticated.
+	c1[0] = 2
+	if _, err = k.Open(nil, c1, 7); err == nil {
+		t.Fatal("Open accepted modified key id")
+	}
+	c1[0] = 1
+
+	if err = k.Retire(2); err != ErrRetireActiveKey {
+		t.Fatalf("got %v - want %v", err, ErrRetireActiveKey)
+	}
+	if err = k.Retire(1); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = k.Open(nil, c1, 7); err != ErrUnknownKey {
+		t.Fatalf("got %v - want %v", err, ErrUnknownKey)
+	}
+	if _, err = KeyID(c1[:KeyringOverhead-1]); err == nil {
+		t.Fatal("KeyID accepted truncated ciphertext")
+	}
+}
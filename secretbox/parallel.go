@@ -0,0 +1,208 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"encoding/binary"
+	"io"
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/aead/hydrogen/auth"
+	"github.com/aead/hydrogen/internal/chacha20"
+	"github.com/aead/hydrogen/subtle"
+)
+
+// ParallelSegmentSize is the size of the segments processed
+// independently by EncryptParallel and DecryptParallel.
+const ParallelSegmentSize = 1 << 20
+
+// parallelThreshold is the msg size at which the keystream
+// is computed by multiple goroutines.
+const parallelThreshold = 4 * ParallelSegmentSize
+
+// EncryptParallel encrypts and authenticates msg like Encrypt but processes
+// segments of ParallelSegmentSize bytes on multiple CPU cores. Therefore the
+// nonce and the mac are computed over the SipHash tags of the segments and not
+// over the msg itself. EncryptParallel produces ciphertexts of the same size as
+// Encrypt but of a different domain - they must be decrypted by DecryptParallel.
+// The ciphertext must be at least 36 bytes longer than the msg, otherwise this
+// function panics. The reader should return random data or can be nil - than
+// the PRNG of the system will be used. The context must be 8 and the key 32
+// bytes long, otherwise this function panics.
+func EncryptParallel(ciphertext, msg []byte, id uint64, rand io.Reader, context, key []byte) {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte
+	readRandom(&random, rand)
+
+	var t [64]byte
+	var nonce [32]byte
+	macKey, nonceKey, encKey := t[:16], t[16:32], t[32:]
+
+	// macKey || nonceKey || encKey = ChaCha12(id||domain , key)
+	deriveKeys(&t, id, domainParallel, key)
+
+	// tmp = SipHash(segment tags of msg, context, nonceKey) ^ random_data
+	// nonce = HChaCha12(zero , tmp)
+	tags := segmentTags(msg, context, nonceKey)
+	hash := auth.New(context, nonceKey)
+	writeTags(hash, nil, len(msg), tags)
+	hash.Sum(nonce[:0])
+	copy(nonce[16:], random[:])
+	chacha20.HChaCha20(nonce[:], zero[:], nonce[:])
+	copy(nonce[20:], zero[:4])
+
+	// enc = XChaCha12(msg, nonce, encKey)
+	// mac = SipHash(nonce||segment tags of enc, context, macKey)
+	// c   = nonce || mac || enc
+	enc := ciphertext[HeaderSize : HeaderSize+len(msg)]
+	forEachSegment(len(msg), func(i, off, end int) {
+		chacha20.XORKeyStreamAt(enc[off:end], msg[off:end], nonce[:24], encKey, uint64(off/64))
+		tags[i] = segmentTag(i, enc[off:end], context, macKey)
+	})
+	copy(ciphertext, nonce[:20])
+
+	hash = auth.New(context, macKey)
+	writeTags(hash, ciphertext[:20], len(msg), tags)
+	hash.Sum(ciphertext[20:20])
+}
+
+// DecryptParallel decrypts a ciphertext encrypted with EncryptParallel and writes
+// the result to msg. Like EncryptParallel it processes the segments of the ciphertext
+// on multiple CPU cores. The msg can be 36 bytes shorter than the ciphertext. The context
+// must be 8 and the key 32 bytes long, otherwise this function panics.
+// This function returns a non-nil error if the ciphertext could not decrypted with
+// the given id, context and key. In this case msg must not be used.
+func DecryptParallel(msg, ciphertext []byte, id uint64, context, key []byte) error {
+	if len(ciphertext) < HeaderSize {
+		return errDecrypt
+	}
+	checkDecrypt(msg, ciphertext, context)
+
+	var t [64]byte
+	var nonce [24]byte
+	macKey, encKey := t[:16], t[32:]
+
+	// macKey || nonceKey || encKey = ChaCha12(id||domain , key)
+	deriveKeys(&t, id, domainParallel, key)
+
+	// mac = SipHash(nonce||segment tags of enc, context, macKey)
+	enc := ciphertext[HeaderSize:]
+	tags := segmentTags(enc, context, macKey)
+
+	var mac [auth.TagSize]byte
+	hash := auth.New(context, macKey)
+	writeTags(hash, ciphertext[:20], len(enc), tags)
+	hash.Sum(mac[:0])
+
+	if !subtle.Equal(ciphertext[20:HeaderSize], mac[:]) {
+		return errDecrypt
+	}
+
+	// msg = XChaCha12(enc, nonce||{0}, encKey)
+	// The enc is moved to msg first such that msg may overlap the ciphertext.
+	copy(nonce[:], ciphertext[:20])
+	msg = msg[:copy(msg, enc)]
+	forEachSegment(len(msg), func(i, off, end int) {
+		chacha20.XORKeyStreamAt(msg[off:end], msg[off:end], nonce[:], encKey, uint64(off/64))
+	})
+	return nil
+}
+
+// segmentTag computes SipHash(0x00 || index || segment, context, key).
+func segmentTag(index int, segment, context, key []byte) [auth.TagSize]byte {
+	var prefix [9]byte
+	binary.LittleEndian.PutUint64(prefix[1:], uint64(index))
+
+	var tag [auth.TagSize]byte
+	hash := auth.New(context, key)
+	hash.Write(prefix[:])
+	hash.Write(segment)
+	hash.Sum(tag[:0])
+	return tag
+}
+
+// segmentTags computes the segment tags of data in parallel.
+func segmentTags(data, context, key []byte) [][auth.TagSize]byte {
+	tags := make([][auth.TagSize]byte, segments(len(data)))
+	forEachSegment(len(data), func(i, off, end int) {
+		tags[i] = segmentTag(i, data[off:end], context, key)
+	})
+	return tags
+}
+
+// writeTags writes 0x01 || nonce || size || tags to the hash.
+func writeTags(hash io.Writer, nonce []byte, size int, tags [][auth.TagSize]byte) {
+	var prefix [9]byte
+	prefix[0] = 1
+	binary.LittleEndian.PutUint64(prefix[1:], uint64(size))
+	hash.Write(prefix[:1])
+	hash.Write(nonce)
+	hash.Write(prefix[1:])
+	for i := range tags {
+		hash.Write(tags[i][:])
+	}
+}
+
+func segments(size int) int {
+	if size == 0 {
+		return 1 // the empty msg consists of one empty segment
+	}
+	return (size + ParallelSegmentSize - 1) / ParallelSegmentSize
+}
+
+// forEachSegment calls fn for every segment [off, end) of a msg of the given
+// size. The calls are distributed over at most GOMAXPROCS goroutines.
+func forEachSegment(size int, fn func(i, off, end int)) {
+	parallel(segments(size), func(i int) {
+		off, end := i*ParallelSegmentSize, (i+1)*ParallelSegmentSize
+		if end > size {
+			end = size
+		}
+		fn(i, off, end)
+	})
+}
+
+// parallel calls fn for every i in [0, n) using a pool of
+// at most GOMAXPROCS goroutines.
+func parallel(n int, fn func(i int)) {
+	workers := runtime.GOMAXPROCS(0)
+	if workers > n {
+		workers = n
+	}
+	if workers <= 1 {
+		for i := 0; i < n; i++ {
+			fn(i)
+		}
+		return
+	}
+
+	var next int64 = -1
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := int(atomic.AddInt64(&next, 1)); i < n; i = int(atomic.AddInt64(&next, 1)) {
+				fn(i)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// xorKeyStream computes the XChaCha12 keystream like chacha20.XORKeyStream
+// but splits large messages into segments processed on multiple CPU cores.
+func xorKeyStream(dst, src, nonce, key []byte) {
+	if len(src) < parallelThreshold || runtime.GOMAXPROCS(0) == 1 {
+		chacha20.XORKeyStream(dst, src, nonce, key)
+		return
+	}
+	forEachSegment(len(src), func(_, off, end int) {
+		chacha20.XORKeyStreamAt(dst[off:end], src[off:end], nonce, key, uint64(off/64))
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+)
+
+func TestEncryptParallel(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+
+	context := []byte("libtests")
+	key := fromHex("b634b3278d800dc126f589ef84d82ab04e0a11bc79c5181e195ddf8f376aad8d")
+	for _, size := range []int{0, 1, 64, ParallelSegmentSize, 2*ParallelSegmentSize + ParallelSegmentSize/2 + 3} {
+		msg := make([]byte, size)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		ciphertext := make([]byte, size+HeaderSize)
+		plaintext := make([]byte, size)
+
+		EncryptParallel(ciphertext, msg, 1, nil, context, key)
+		if err := DecryptParallel(plaintext, ciphertext, 1, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+			t.Fatalf("%d: DecryptParallel failed: %v", size, err)
+		}
+		if Decrypt(plaintext, ciphertext, 1, context, key) == nil {
+			t.Fatalf("%d: Decrypt accepted parallel ciphertext", size)
+		}
+		if DecryptParallel(plaintext, ciphertext, 2, context, key) == nil {
+			t.Fatalf("%d: DecryptParallel accepted wrong msg id", size)
+		}
+		if size > 0 {
+			ciphertext[len(ciphertext)-1] ^= 1
+			if DecryptParallel(plaintext, ciphertext, 1, context, key) == nil {
+				t.Fatalf("%d: DecryptParallel accepted modified ciphertext", size)
+			}
+		}
+	}
+}
+
+func TestParallelSegmentOrder(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := make([]byte, 2*ParallelSegmentSize)
+	ciphertext := make([]byte, len(msg)+HeaderSize)
+	EncryptParallel(ciphertext, msg, 1, nil, context, key)
+
+	// Swap the two segments of the ciphertext.
+	enc := ciphertext[HeaderSize:]
+	tmp := make([]byte, ParallelSegmentSize)
+	copy(tmp, enc[:ParallelSegmentSize])
+	copy(enc, enc[ParallelSegmentSize:])
+	copy(enc[ParallelSegmentSize:], tmp)
+	if DecryptParallel(msg, ciphertext, 1, context, key) == nil {
+		t.Fatal("DecryptParallel accepted reordered segments")
+	}
+}
+
+func TestParallelKeyStream(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := make([]byte, parallelThreshold+ParallelSegmentSize/2)
+	for i := range msg {
+		msg[i] = byte(i * 7)
+	}
+
+	random := make([]byte, 16)
+	sequential := make([]byte, len(msg)+HeaderSize)
+	parallel := make([]byte, len(msg)+HeaderSize)
+
+	procs := runtime.GOMAXPROCS(1)
+	Encrypt(sequential, msg, 1, bytes.NewReader(random), context, key)
+	runtime.GOMAXPROCS(4)
+	Encrypt(parallel, msg, 1, bytes.NewReader(random), context, key)
+	runtime.GOMAXPROCS(procs)
+
+	if !bytes.Equal(sequential, parallel) {
+		t.Fatal("parallel keystream differs from sequential keystream")
+	}
+
+	// Open with ciphertext[:0] as dst decrypts in place.
+	plaintext, err := Open(parallel[:0], parallel, 1, context, key)
+	if err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("in-place Open failed: %v", err)
+	}
+}
+
+func benchEncryptParallel(size int, b *testing.B) {
+	key := make([]byte, KeySize)
+	context := make([]byte, 8)
+	msg := make([]byte, size)
+	ciphertext := make([]byte, size+HeaderSize)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		EncryptParallel(ciphertext, msg, 0, nil, context, key)
+	}
+}
+
+func BenchmarkEncryptParallel1M(b *testing.B)  { benchEncryptParallel(1<<20, b) }
+func BenchmarkEncryptParallel16M(b *testing.B) { benchEncryptParallel(16<<20, b) }
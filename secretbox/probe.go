@@ -0,0 +1,48 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"strconv"
+
+	"github.com/aead/hydrogen/auth"
+	"github.com/aead/hydrogen/subtle"
+)
+
+// ProbeSize is the size of a probe in bytes.
+const ProbeSize = auth.TagSize
+
+// ProbeCreate returns a probe for the given ciphertext. A peer knowing the
+// key can prove that it is able to decrypt the ciphertext by sending back
+// the probe. The probe reveals nothing about the plaintext.
+// The ciphertext must be at least 36 bytes long, the context must be 8 and
+// the key 32 bytes long, otherwise this function panics.
+func ProbeCreate(ciphertext, context, key []byte) [ProbeSize]byte {
+	if len(ciphertext) < HeaderSize {
+		panic("hydrogen/secretbox: ciphertext is too small")
+	}
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+
+	// probe = SipHash(mac, context, probeKey)
+	var t [64]byte
+	deriveKeys(&t, 0, domainProbe, key)
+	return auth.Sum(ciphertext[20:HeaderSize], context, t[:16])
+}
+
+// ProbeVerify returns true if and only if the probe has been created
+// by ProbeCreate for the given ciphertext, context and key. The context
+// must be 8 and the key 32 bytes long, otherwise this function panics.
+func ProbeVerify(probe [ProbeSize]byte, ciphertext, context, key []byte) bool {
+	if len(ciphertext) < HeaderSize {
+		return false
+	}
+	p := ProbeCreate(ciphertext, context, key)
+	return subtle.Equal(probe[:], p[:])
+}
@@ -0,0 +1,35 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import "testing"
+
+func TestProbe(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("b634b3278d800dc126f589ef84d82ab04e0a11bc79c5181e195ddf8f376aad8d")
+	badKey := make([]byte, KeySize)
+	msg := []byte("Hello World")
+	ciphertext := make([]byte, len(msg)+HeaderSize)
+	Encrypt(ciphertext, msg, 0, nil, context, key)
+
+	probe := ProbeCreate(ciphertext, context, key)
+	if !ProbeVerify(probe, ciphertext, context, key) {
+		t.Fatal("ProbeVerify rejected valid probe")
+	}
+	if ProbeVerify(probe, ciphertext, []byte("wrongctx"), key) {
+		t.Fatal("ProbeVerify accepted wrong context")
+	}
+	if ProbeVerify(probe, ciphertext, context, badKey) {
+		t.Fatal("ProbeVerify accepted wrong key")
+	}
+	if ProbeVerify(probe, ciphertext[:HeaderSize-1], context, key) {
+		t.Fatal("ProbeVerify accepted truncated ciphertext")
+	}
+
+	Encrypt(ciphertext, msg, 0, nil, context, key)
+	if ProbeVerify(probe, ciphertext, context, key) {
+		t.Fatal("ProbeVerify accepted probe of a different ciphertext")
+	}
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+)
+
+var (
+	// ErrReplay is returned by a Receiver if a msg id was already
+	// accepted or is too old to be checked.
+	ErrReplay = errors.New("hydrogen/secretbox: duplicate or too old msg id")
+	// ErrSequenceExhausted is returned by a Sender if all msg ids
+	// are used.
+	ErrSequenceExhausted = errors.New("hydrogen/secretbox: msg ids exhausted")
+)
+
+// SequenceStore persists the msg ids reserved by a Sender.
+type SequenceStore interface {
+	// Load returns the last stored value or 0 if
+	// no value has been stored yet.
+	Load() (uint64, error)
+
+	// Store persists the value. Store must not return
+	// before the value is durably stored.
+	Store(uint64) error
+}
+
+// Sender hands out monotonically increasing msg ids. It reserves blocks of
+// msg ids by persisting the upper bound of the block to its SequenceStore
+// before any id of the block is used. Therefore a restarted Sender never
+// reuses a msg id - but skips the unused ids of the last block.
+// It is safe to use a Sender from multiple goroutines.
+type Sender struct {
+	lock        sync.Mutex
+	store       SequenceStore
+	blockSize   uint64
+	next, limit uint64
+}
+
+// NewSender returns a new Sender which reserves blockSize msg ids at once.
+// The first msg id is the value loaded from the store. NewSender returns a
+// non-nil error if the store fails. The blockSize must be positive, otherwise
+// this function panics.
+func NewSender(store SequenceStore, blockSize int) (*Sender, error) {
+	if blockSize <= 0 {
+		panic("hydrogen/secretbox: invalid block size " + strconv.Itoa(blockSize))
+	}
+	next, err := store.Load()
+	if err != nil {
+		return nil, err
+	}
+	return &Sender{
+		store:     store,
+		blockSize: uint64(blockSize),
+		next:      next,
+		limit:     next,
+	}, nil
+}
+
+// Next returns the next msg id. It returns a non-nil error if a new block
+// of msg ids could not be reserved or all msg ids are used.
+func (s *Sender) Next() (uint64, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if s.next == s.limit {
+		if s.limit == 1<<64-1 {
+			return 0, ErrSequenceExhausted
+		}
+		limit := s.limit + s.blockSize
+		if limit < s.limit {
+			limit = 1<<64 - 1
+		}
+		if err := s.store.Store(limit); err != nil {
+			return 0, err
+		}
+		s.limit = limit
+	}
+	id := s.next
+	s.next++
+	return id, nil
+}
+
+// FileStore is a SequenceStore which persists the value to a file.
+type FileStore struct {
+	path string
+}
+
+// NewFileStore returns a SequenceStore which persists the value to
+// the file at the given path.
+func NewFileStore(path string) *FileStore { return &FileStore{path: path} }
+
+// Load returns the value stored in the file or 0 if the file does not exist.
+func (s *FileStore) Load() (uint64, error) {
+	b, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	if len(b) != 8 {
+		return 0, errors.New("hydrogen/secretbox: invalid sequence file " + s.path)
+	}
+	return binary.LittleEndian.Uint64(b), nil
+}
+
+// Store atomically replaces the file content with the value
+// and syncs the file and its directory to disk.
+func (s *FileStore) Store(v uint64) error {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(b[:]); err == nil {
+		err = f.Sync()
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err = os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	// Sync the directory such that the rename is durable.
+	dir, err := os.Open(filepath.Dir(s.path))
+	if err != nil {
+		return err
+	}
+	err = dir.Sync()
+	if cerr := dir.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Receiver rejects replayed messages. It keeps a sliding window of the
+// most recent msg ids - similar to the anti-replay window of IPsec - and
+// accepts every msg id at most once. Msg ids older than the window are
+// rejected. It is safe to use a Receiver from multiple goroutines.
+type Receiver struct {
+	lock    sync.Mutex
+	size    uint64
+	bitmap  []uint64
+	highest uint64
+	seen    bool
+}
+
+// NewReceiver returns a new Receiver with a window of windowSize msg ids.
+// The windowSize must be positive, otherwise this function panics.
+func NewReceiver(windowSize int) *Receiver {
+	if windowSize <= 0 {
+		panic("hydrogen/secretbox: invalid window size " + strconv.Itoa(windowSize))
+	}
+	// One additional word such that the current word never
+	// overlaps with the oldest word of the window.
+	words := (windowSize+63)/64 + 1
+	return &Receiver{
+		size:   uint64(windowSize),
+		bitmap: make([]uint64, words),
+	}
+}
+
+// Open decrypts and verifies the ciphertext like the Open function and
+// accepts the msg id afterwards. It returns ErrReplay if the msg id was
+// already accepted or is too old. A ciphertext which could not be decrypted
+// does not affect the window.
+func (r *Receiver) Open(dst, ciphertext []byte, id uint64, context, key []byte) ([]byte, error) {
+	ret, err := Open(dst, ciphertext, id, context, key)
+	if err != nil {
+		return nil, err
+	}
+	if err = r.Accept(id); err != nil {
+		for i := range ret[len(dst):] {
+			ret[len(dst)+i] = 0
+		}
+		return nil, err
+	}
+	return ret, nil
+}
+
+// Accept marks the msg id as seen. It returns ErrReplay if the msg id was
+// already accepted or is too old. Accept must only be called for msg ids of
+// successfully authenticated messages.
+func (r *Receiver) Accept(id uint64) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	n := uint64(len(r.bitmap))
+	word, bit := (id/64)%n, uint64(1)<<(id%64)
+	if r.seen && id <= r.highest {
+		if r.highest-id >= r.size {
+			return ErrReplay
+		}
+		if r.bitmap[word]&bit != 0 {
+			return ErrReplay
+		}
+		r.bitmap[word] |= bit
+		return nil
+	}
+
+	// Slide the window and clear the words of the new msg ids.
+	diff := n
+	if r.seen && id/64-r.highest/64 < n {
+		diff = id/64 - r.highest/64
+	}
+	for i := uint64(0); i < diff; i++ {
+		r.bitmap[(id/64-i)%n] = 0
+	}
+	r.highest, r.seen = id, true
+	r.bitmap[word] |= bit
+	return nil
+}
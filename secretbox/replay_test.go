@@ -0,0 +1,125 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memoryStore struct{ value, stores uint64 }
+
+func (s *memoryStore) Load() (uint64, error) { return s.value, nil }
+
+func (s *memoryStore) Store(v uint64) error {
+	s.value = v
+	s.stores++
+	return nil
+}
+
+func TestSender(t *testing.T) {
+	store := new(memoryStore)
+	s, err := NewSender(store, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := uint64(0); i < 25; i++ {
+		id, err := s.Next()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != i {
+			t.Fatalf("got msg id %d - want %d", id, i)
+		}
+	}
+	if store.value != 30 || store.stores != 3 {
+		t.Fatalf("got stored value %d after %d stores - want 30 after 3 stores", store.value, store.stores)
+	}
+
+	// A restarted sender continues after the reserved block.
+	if s, err = NewSender(store, 10); err != nil {
+		t.Fatal(err)
+	}
+	if id, _ := s.Next(); id != 30 {
+		t.Fatalf("got msg id %d after restart - want 30", id)
+	}
+
+	store.value = 1<<64 - 2
+	if s, err = NewSender(store, 10); err != nil {
+		t.Fatal(err)
+	}
+	if id, err := s.Next(); err != nil || id != 1<<64-2 {
+		t.Fatalf("got msg id %d: %v", id, err)
+	}
+	if _, err = s.Next(); err != ErrSequenceExhausted {
+		t.Fatalf("got %v - want %v", err, ErrSequenceExhausted)
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hydrogen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := NewFileStore(filepath.Join(dir, "seq"))
+	if v, err := store.Load(); err != nil || v != 0 {
+		t.Fatalf("Load of missing file: got %d: %v", v, err)
+	}
+	if err = store.Store(1 << 40); err != nil {
+		t.Fatal(err)
+	}
+	if v, err := NewFileStore(filepath.Join(dir, "seq")).Load(); err != nil || v != 1<<40 {
+		t.Fatalf("Load: got %d: %v", v, err)
+	}
+}
+
+var receiverTest = []struct {
+	id     uint64
+	replay bool
+}{
+	{5, false}, {5, true}, {3, false}, {3, true},
+	{100, false}, {37, false}, {36, true}, {5, true},
+	{99, false}, {100, true}, {1000, false}, {937, false},
+	{936, true}, {100, true}, {1000, true}, {1001, false},
+}
+
+func TestReceiver(t *testing.T) {
+	r := NewReceiver(64)
+	for i, v := range receiverTest {
+		err := r.Accept(v.id)
+		if v.replay && err != ErrReplay {
+			t.Fatalf("%d: Accept(%d): got %v - want %v", i, v.id, err, ErrReplay)
+		}
+		if !v.replay && err != nil {
+			t.Fatalf("%d: Accept(%d): %v", i, v.id, err)
+		}
+	}
+}
+
+func TestReceiverOpen(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("Hello World")
+	r := NewReceiver(128)
+
+	ciphertext := Seal(nil, msg, 7, context, key)
+	ciphertext[len(ciphertext)-1] ^= 1
+	if _, err := r.Open(nil, ciphertext, 7, context, key); err == nil || err == ErrReplay {
+		t.Fatalf("Open accepted modified ciphertext: %v", err)
+	}
+	ciphertext[len(ciphertext)-1] ^= 1
+
+	if p, err := r.Open(nil, ciphertext, 7, context, key); err != nil || !bytes.Equal(p, msg) {
+		t.Fatalf("Open failed: %v", err)
+	}
+	if _, err := r.Open(nil, ciphertext, 7, context, key); err != ErrReplay {
+		t.Fatalf("got %v - want %v", err, ErrReplay)
+	}
+}
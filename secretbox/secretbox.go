@@ -15,6 +15,7 @@ import (
 	crand "crypto/rand"
 	"encoding/binary"
 	"errors"
+	"hash"
 	"io"
 	"strconv"
 
@@ -32,6 +33,27 @@ const (
 
 var zero = [16]byte{}
 
+// The domains separate the subkeys of the different constructions
+// based on the same key.
+const (
+	domainBox byte = iota
+	domainProbe
+	domainAD
+	domainSIV
+	domainParallel
+	domainKeyring
+	domainFramed
+)
+
+// deriveKeys computes the subkeys macKey || nonceKey || encKey of the
+// given domain and msg id and writes them to t.
+func deriveKeys(t *[64]byte, id uint64, domain byte, key []byte) {
+	var nonce [16]byte
+	binary.LittleEndian.PutUint64(nonce[:], id)
+	nonce[8] = domain
+	chacha20.Core(t, nonce[:], key)
+}
+
 // GenerateKey returns a random en/decryption key.
 // Therefore the given reader must return random data.
 // This function returns a non-nil error if the given reader
@@ -49,9 +71,62 @@ func GenerateKey(rand io.Reader) (key []byte, err error) {
 // Encrypt encrypts and authenticates msg and writes the result to ciphertext.
 // The ciphertext must be at least 36 bytes longer than the msg, otherwise this
 // function panics. The reader should return random data or can be nil - than the
-// PRNG of the system will be used. The context must be 8 and the key 32 bytes long,
-// otherwise this function panics.
+// PRNG of the system will be used. Encrypt ignores errors of the reader - use
+// Config.Encrypt to detect a failing RNG. The context must be 8 and the key 32 bytes
+// long, otherwise this function panics.
 func Encrypt(ciphertext, msg []byte, id uint64, rand io.Reader, context, key []byte) {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte
+	readRandom(&random, rand)
+	encrypt(ciphertext, msg, nil, id, domainBox, &random, context, key)
+}
+
+// EncryptAD encrypts and authenticates msg, authenticates the additional data ad
+// and writes the result to ciphertext. The additional data is not part of the
+// ciphertext - it must be passed to DecryptAD again. Ciphertexts created by
+// EncryptAD cannot be decrypted by Decrypt and vice versa. The ciphertext must be
+// at least 36 bytes longer than the msg, otherwise this function panics.
+// The reader should return random data or can be nil - than the PRNG of the
+// system will be used. Like Encrypt, EncryptAD ignores errors of the reader.
+// The context must be 8 and the key 32 bytes long, otherwise this function panics.
+func EncryptAD(ciphertext, msg, ad []byte, id uint64, rand io.Reader, context, key []byte) {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte
+	readRandom(&random, rand)
+	encrypt(ciphertext, msg, ad, id, domainAD, &random, context, key)
+}
+
+var errDecrypt = errors.New("hydrogen/secretbox: authentication failed")
+
+// Decrypt decrypts a ciphertext encrypted with Encrypt and writes the result to msg.
+// The msg can be 36 bytes shorter than the ciphertext. The context must be 8 and the
+// key 32 bytes long, otherwise this function panics.
+// This function returns a non-nil error if the ciphertext could not decrypted with
+// the given id, context and key. In this case msg must not be used.
+func Decrypt(msg, ciphertext []byte, id uint64, context, key []byte) (err error) {
+	if len(ciphertext) < HeaderSize {
+		return errDecrypt
+	}
+	checkDecrypt(msg, ciphertext, context)
+	return decrypt(msg, ciphertext, nil, id, domainBox, context, key)
+}
+
+// DecryptAD decrypts a ciphertext encrypted with EncryptAD, verifies the additional
+// data ad and writes the result to msg. The msg can be 36 bytes shorter than the
+// ciphertext. The context must be 8 and the key 32 bytes long, otherwise this function
+// panics. This function returns a non-nil error if the ciphertext could not decrypted
+// with the given additional data, id, context and key. In this case msg must not be used.
+func DecryptAD(msg, ciphertext, ad []byte, id uint64, context, key []byte) (err error) {
+	if len(ciphertext) < HeaderSize {
+		return errDecrypt
+	}
+	checkDecrypt(msg, ciphertext, context)
+	return decrypt(msg, ciphertext, ad, id, domainAD, context, key)
+}
+
+func checkEncrypt(ciphertext, msg, context, key []byte) {
 	if len(ciphertext) < len(msg)+HeaderSize {
 		panic("hydrogen/secretbox: ciphertext is too small")
 	}
@@ -61,79 +136,93 @@ func Encrypt(ciphertext, msg []byte, id uint64, rand io.Reader, context, key []b
 	if c := len(context); c != 8 {
 		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
 	}
+}
+
+func checkDecrypt(msg, ciphertext, context []byte) {
+	if len(msg) < len(ciphertext)-HeaderSize {
+		panic("hydrogen/secretbox: msg buffer is to small")
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+}
+
+// newHash returns a SipHash instance keyed with context and key. In the
+// domains with additional data the length-prefixed additional data is
+// written to the hash.
+func newHash(ad []byte, domain byte, context, key []byte) hash.Hash {
+	h := auth.New(context, key)
+	if domain == domainAD || domain == domainKeyring || domain == domainFramed {
+		var n [8]byte
+		binary.LittleEndian.PutUint64(n[:], uint64(len(ad)))
+		h.Write(n[:])
+		h.Write(ad)
+	}
+	return h
+}
+
+// readRandom reads 16 bytes into random from rand or the PRNG of the system if rand is nil.
+// The error of the reader is ignored - secretbox does not rely on the random
+// data to be unique.
+func readRandom(random *[16]byte, rand io.Reader) {
 	if rand == nil {
 		rand = crand.Reader // use global RNG
 	}
+	io.ReadFull(rand, random[:])
+}
 
+func encrypt(ciphertext, msg, ad []byte, id uint64, domain byte, random *[16]byte, context, key []byte) {
 	var t [64]byte
 	var nonce [32]byte
 	macKey, nonceKey, encKey := t[:16], t[16:32], t[32:]
 
-	// macKey || nonceKey || encKey = ChaCha12(id||{0} , key)
-	binary.LittleEndian.PutUint64(t[:], id)
-	chacha20.Core(&t, t[:16], key)
+	// macKey || nonceKey || encKey = ChaCha12(id||domain , key)
+	deriveKeys(&t, id, domain, key)
 
-	// tmp = SipHash(msg, context, nonceKey) ^ random_data
+	// tmp = SipHash([len(ad)||ad||]msg, context, nonceKey) ^ random_data
 	// nonce = HChaCha12(zero , tmp)
-	k := auth.Sum(msg, context, nonceKey)
-	copy(nonce[:], k[:])
-	rand.Read(nonce[16:]) // TODO(aead): Decide - fail if read fails or assume nothing about rand
+	hash := newHash(ad, domain, context, nonceKey)
+	hash.Write(msg)
+	hash.Sum(nonce[:0])
+	copy(nonce[16:], random[:])
 	chacha20.HChaCha20(nonce[:], zero[:], nonce[:])
 	copy(nonce[20:], zero[:4])
 
 	// enc = XChaCha12(msg, nonce, encKey)
-	// mac = SipHash(nonce||enc, context, macKey)
+	// mac = SipHash([len(ad)||ad||]nonce||enc, context, macKey)
 	// c   = nonce || mac || enc
-	chacha20.XORKeyStream(ciphertext[HeaderSize:], msg, nonce[:24], encKey)
+	xorKeyStream(ciphertext[HeaderSize:], msg, nonce[:24], encKey)
 	copy(ciphertext, nonce[:20])
 
-	hash := auth.New(context, macKey)
+	hash = newHash(ad, domain, context, macKey)
 	hash.Write(ciphertext[:20])
 	hash.Write(ciphertext[HeaderSize:])
 	hash.Sum(ciphertext[20:20])
 }
 
-var errDecrypt = errors.New("hydrogen/secretbox: authentication failed")
-
-// Decrypt decrypts a ciphertext encrypted with Encrypt and writes the result to msg.
-// The msg can be 36 bytes shorter than the ciphertext. The context must be 8 and the
-// key 32 bytes long, otherwise this function panics.
-// This function returns a non-nil error if the ciphertext could not decrypted with
-// the given id, context and key. In this case msg must not be used.
-func Decrypt(msg, ciphertext []byte, id uint64, context, key []byte) (err error) {
-	if c := len(ciphertext); c < HeaderSize {
-		err = errDecrypt
-		return
-	}
-	if len(msg) < len(ciphertext)-HeaderSize {
-		panic("hydrogen/secretbox: msg buffer is to small")
-	}
-	if c := len(context); c != 8 {
-		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
-	}
-
+func decrypt(msg, ciphertext, ad []byte, id uint64, domain byte, context, key []byte) error {
 	var t [64]byte
 	var nonce [24]byte
 	macKey, encKey := t[:16], t[32:]
 
-	// macKey || nonceKey || encKey = ChaCha12(id||{0} , key)
-	binary.LittleEndian.PutUint64(t[:], id)
-	chacha20.Core(&t, t[:16], key)
+	// macKey || nonceKey || encKey = ChaCha12(id||domain , key)
+	deriveKeys(&t, id, domain, key)
 
-	// mac = SipHash(nonce||enc, context, macKey)
+	// mac = SipHash([len(ad)||ad||]nonce||enc, context, macKey)
 	var mac [auth.TagSize]byte
-	hash := auth.New(context, macKey)
+	hash := newHash(ad, domain, context, macKey)
 	hash.Write(ciphertext[:20])
 	hash.Write(ciphertext[HeaderSize:])
 	hash.Sum(mac[:0])
 
 	if !subtle.Equal(ciphertext[20:HeaderSize], mac[:]) {
-		err = errDecrypt
-		return
+		return errDecrypt
 	}
 
 	// msg = XChaCha12(enc, nonce||{0}, encKey)
+	// The enc is moved to msg first such that msg may overlap the ciphertext.
 	copy(nonce[:], ciphertext[:20])
-	chacha20.XORKeyStream(msg, ciphertext[HeaderSize:], nonce[:], encKey)
-	return
+	msg = msg[:copy(msg, ciphertext[HeaderSize:])]
+	xorKeyStream(msg, msg, nonce[:], encKey)
+	return nil
 }
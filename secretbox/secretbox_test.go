@@ -51,6 +51,38 @@ func TestTolerance(t *testing.T) {
 	}
 }
 
+func TestEncryptAD(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("b634b3278d800dc126f589ef84d82ab04e0a11bc79c5181e195ddf8f376aad8d")
+	msg := fromHex("e1047ba9476bf8ff312c01b4345a7d8ca5792b0ad467313f1d")
+	ad := []byte("row:42")
+	ciphertext := make([]byte, len(msg)+HeaderSize)
+	plaintext := make([]byte, len(msg))
+
+	EncryptAD(ciphertext, msg, ad, 1, nil, context, key)
+	if err := DecryptAD(plaintext, ciphertext, ad, 1, context, key); err != nil || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("DecryptAD failed: %v", err)
+	}
+	if DecryptAD(plaintext, ciphertext, []byte("row:43"), 1, context, key) == nil {
+		t.Fatal("DecryptAD accepted wrong additional data")
+	}
+	if DecryptAD(plaintext, ciphertext, nil, 1, context, key) == nil {
+		t.Fatal("DecryptAD accepted missing additional data")
+	}
+	if Decrypt(plaintext, ciphertext, 1, context, key) == nil {
+		t.Fatal("Decrypt accepted EncryptAD ciphertext")
+	}
+
+	// Empty additional data still selects the AD domain.
+	EncryptAD(ciphertext, msg, nil, 1, nil, context, key)
+	if DecryptAD(plaintext, ciphertext, nil, 1, context, key) != nil {
+		t.Fatal("DecryptAD rejected empty additional data")
+	}
+	if Decrypt(plaintext, ciphertext, 1, context, key) == nil {
+		t.Fatal("Decrypt accepted EncryptAD ciphertext with empty additional data")
+	}
+}
+
 func benchEncrypt(size int, b *testing.B) {
 	key := make([]byte, KeySize)
 	context := make([]byte, 8)
@@ -0,0 +1,60 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"github.com/aead/hydrogen/auth"
+	"github.com/aead/hydrogen/internal/chacha20"
+	"github.com/aead/hydrogen/subtle"
+)
+
+// EncryptDeterministic encrypts and authenticates msg without randomness and
+// writes the result to ciphertext. The nonce is derived from the msg only, so
+// equal messages encrypted with the same id, context and key produce equal
+// ciphertexts. Therefore EncryptDeterministic reveals whether two messages are
+// equal but nothing else - use it only if this is intended, e.g. for deduplication
+// or key wrapping. The ciphertexts are bound to their own domain and cannot be
+// decrypted by Decrypt and vice versa.
+// The ciphertext must be at least 36 bytes longer than the msg, the context must
+// be 8 and the key 32 bytes long, otherwise this function panics.
+func EncryptDeterministic(ciphertext, msg []byte, id uint64, context, key []byte) {
+	checkEncrypt(ciphertext, msg, context, key)
+
+	var random [16]byte // the synthetic IV does not contain random data
+	encrypt(ciphertext, msg, nil, id, domainSIV, &random, context, key)
+}
+
+// DecryptDeterministic decrypts a ciphertext encrypted with EncryptDeterministic
+// and writes the result to msg. Besides the mac it verifies that the nonce was
+// derived from the decrypted msg. The msg can be 36 bytes shorter than the ciphertext.
+// The context must be 8 and the key 32 bytes long, otherwise this function panics.
+// This function returns a non-nil error if the ciphertext could not decrypted with
+// the given id, context and key. In this case msg must not be used.
+func DecryptDeterministic(msg, ciphertext []byte, id uint64, context, key []byte) error {
+	if len(ciphertext) < HeaderSize {
+		return errDecrypt
+	}
+	checkDecrypt(msg, ciphertext, context)
+	if err := decrypt(msg, ciphertext, nil, id, domainSIV, context, key); err != nil {
+		return err
+	}
+	msg = msg[:len(ciphertext)-HeaderSize]
+
+	// nonce = HChaCha12(zero, SipHash(msg, context, nonceKey) || zero)
+	var t [64]byte
+	var nonce [32]byte
+	deriveKeys(&t, id, domainSIV, key)
+	tag := auth.Sum(msg, context, t[16:32])
+	copy(nonce[:], tag[:])
+	chacha20.HChaCha20(nonce[:], zero[:], nonce[:])
+
+	if !subtle.Equal(nonce[:20], ciphertext[:20]) {
+		for i := range msg {
+			msg[i] = 0
+		}
+		return errDecrypt
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEncryptDeterministic(t *testing.T) {
+	context := []byte("libtests")
+	key := fromHex("b634b3278d800dc126f589ef84d82ab04e0a11bc79c5181e195ddf8f376aad8d")
+	msg := fromHex("e1047ba9476bf8ff312c01b4345a7d8ca5792b0ad467313f1d")
+
+	for i := range msg {
+		c1 := make([]byte, i+HeaderSize)
+		c2 := make([]byte, i+HeaderSize)
+		EncryptDeterministic(c1, msg[:i], uint64(i), context, key)
+		EncryptDeterministic(c2, msg[:i], uint64(i), context, key)
+		if !bytes.Equal(c1, c2) {
+			t.Fatalf("%d: EncryptDeterministic is not deterministic", i)
+		}
+		EncryptDeterministic(c2, msg[:i], uint64(i+1), context, key)
+		if bytes.Equal(c1, c2) {
+			t.Fatalf("%d: equal ciphertexts for different msg ids", i)
+		}
+
+		p := make([]byte, i)
+		if err := DecryptDeterministic(p, c1, uint64(i), context, key); err != nil || !bytes.Equal(p, msg[:i]) {
+			t.Fatalf("%d: DecryptDeterministic failed: %v", i, err)
+		}
+		if Decrypt(p, c1, uint64(i), context, key) == nil {
+			t.Fatalf("%d: Decrypt accepted deterministic ciphertext", i)
+		}
+		if DecryptDeterministic(p, c1, uint64(i), []byte("wrongctx"), key) == nil {
+			t.Fatalf("%d: DecryptDeterministic accepted wrong context", i)
+		}
+
+		Encrypt(c2, msg[:i], uint64(i), nil, context, key)
+		if DecryptDeterministic(p, c2, uint64(i), context, key) == nil {
+			t.Fatalf("%d: DecryptDeterministic accepted randomized ciphertext", i)
+		}
+
+		// Valid mac but the nonce is not derived from the msg.
+		random := [16]byte{1}
+		encrypt(c2, msg[:i], nil, uint64(i), domainSIV, &random, context, key)
+		if DecryptDeterministic(p, c2, uint64(i), context, key) == nil {
+			t.Fatalf("%d: DecryptDeterministic accepted invalid synthetic IV", i)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	crand "crypto/rand"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/aead/hydrogen/internal/chacha20"
+)
+
+const (
+	// ChunkSize is the size of one plaintext chunk of a stream in bytes.
+	ChunkSize = 64 * 1024
+	// StreamHeaderSize is the size of the header of a stream in bytes.
+	StreamHeaderSize = 16
+
+	finalChunk = 1 << 63
+)
+
+var (
+	errTruncated = errors.New("hydrogen/secretbox: stream is truncated")
+	errTrailing  = errors.New("hydrogen/secretbox: unexpected data after final chunk")
+)
+
+// NewWriter returns a new io.WriteCloser encrypting everything written to
+// it and writing the ciphertext to w. The data is split into chunks of
+// ChunkSize bytes and every chunk is encrypted with Encrypt using the chunk
+// counter as msg id. The last chunk is marked as final chunk, such that
+// a reader detects reordered, removed or truncated chunks.
+//
+// The stream starts with a random header of 16 bytes which is written to w
+// immediately. It is used to derive a stream specific key from the given key.
+// Close must be called to write the final chunk. It does not close w.
+// The context must be 8 and the key 32 bytes long, otherwise this function
+// panics.
+func NewWriter(w io.Writer, context, key []byte) (io.WriteCloser, error) {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	var header [StreamHeaderSize]byte
+	if _, err := io.ReadFull(crand.Reader, header[:]); err != nil {
+		return nil, err
+	}
+	if _, err := w.Write(header[:]); err != nil {
+		return nil, err
+	}
+	sw := &streamWriter{
+		w:          w,
+		context:    make([]byte, 8),
+		buf:        make([]byte, 0, ChunkSize),
+		ciphertext: make([]byte, ChunkSize+HeaderSize),
+	}
+	copy(sw.context, context)
+	chacha20.HChaCha20(sw.key[:], header[:], key)
+	return sw, nil
+}
+
+// NewReader returns a new io.Reader reading and decrypting a stream
+// created by a writer returned from NewWriter. The reader returns a
+// non-nil error if the stream cannot be authenticated or is truncated.
+// In this case all data read so far must not be used.
+// The context must be 8 and the key 32 bytes long, otherwise this
+// function panics.
+func NewReader(r io.Reader, context, key []byte) (io.Reader, error) {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretbox: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretbox: invalid context size " + strconv.Itoa(c))
+	}
+	var header [StreamHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		if err == io.EOF {
+			err = errTruncated
+		}
+		return nil, err
+	}
+	sr := &streamReader{
+		r:          r,
+		context:    make([]byte, 8),
+		buf:        make([]byte, 0, ChunkSize),
+		ciphertext: make([]byte, ChunkSize+HeaderSize),
+	}
+	copy(sr.context, context)
+	chacha20.HChaCha20(sr.key[:], header[:], key)
+	return sr, nil
+}
+
+type streamWriter struct {
+	w          io.Writer
+	context    []byte
+	key        [KeySize]byte
+	buf        []byte
+	ciphertext []byte
+	counter    uint64
+	err        error
+}
+
+func (w *streamWriter) Write(p []byte) (n int, err error) {
+	if w.err != nil {
+		return 0, w.err
+	}
+	for len(p) > 0 {
+		if len(w.buf) == ChunkSize { // only flush if we know this is not the last chunk
+			if err = w.flush(false); err != nil {
+				return
+			}
+		}
+		c := copy(w.buf[len(w.buf):ChunkSize], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		n += c
+		p = p[c:]
+	}
+	return
+}
+
+func (w *streamWriter) Close() error {
+	if w.err != nil {
+		if w.err == errClosed {
+			return nil
+		}
+		return w.err
+	}
+	if err := w.flush(true); err != nil {
+		return err
+	}
+	w.err = errClosed
+	return nil
+}
+
+var errClosed = errors.New("hydrogen/secretbox: write to closed stream")
+
+func (w *streamWriter) flush(final bool) error {
+	id := w.counter
+	if final {
+		id |= finalChunk
+	}
+	ciphertext := w.ciphertext[:len(w.buf)+HeaderSize]
+	Encrypt(ciphertext, w.buf, id, nil, w.context, w.key[:])
+	if _, err := w.w.Write(ciphertext); err != nil {
+		w.err = err
+		return err
+	}
+	w.buf = w.buf[:0]
+	w.counter++
+	return nil
+}
+
+type streamReader struct {
+	r          io.Reader
+	context    []byte
+	key        [KeySize]byte
+	buf        []byte
+	off        int
+	ciphertext []byte
+	counter    uint64
+	err        error
+}
+
+func (r *streamReader) Read(p []byte) (n int, err error) {
+	for r.off == len(r.buf) {
+		if r.err != nil {
+			return 0, r.err
+		}
+		r.err = r.next()
+	}
+	n = copy(p, r.buf[r.off:])
+	r.off += n
+	return
+}
+
+// next reads and decrypts the next chunk. It returns io.EOF
+// after the final chunk.
+func (r *streamReader) next() error {
+	n, err := io.ReadFull(r.r, r.ciphertext)
+	switch err {
+	default:
+		return err
+	case io.EOF:
+		return errTruncated
+	case io.ErrUnexpectedEOF: // only the final chunk can be shorter than ChunkSize
+		if n < HeaderSize {
+			return errTruncated
+		}
+		if err = r.decrypt(r.ciphertext[:n], r.counter|finalChunk); err != nil {
+			return err
+		}
+		return io.EOF
+	case nil:
+		if r.decrypt(r.ciphertext, r.counter) == nil {
+			r.counter++
+			return nil
+		}
+		if err = r.decrypt(r.ciphertext, r.counter|finalChunk); err != nil {
+			return err
+		}
+		var b [1]byte
+		if n, _ = io.ReadFull(r.r, b[:]); n > 0 {
+			r.buf = r.buf[:0]
+			return errTrailing
+		}
+		return io.EOF
+	}
+}
+
+func (r *streamReader) decrypt(ciphertext []byte, id uint64) error {
+	r.buf = r.buf[:len(ciphertext)-HeaderSize]
+	r.off = 0
+	if err := Decrypt(r.buf, ciphertext, id, r.context, r.key[:]); err != nil {
+		r.buf = r.buf[:0]
+		return err
+	}
+	return nil
+}
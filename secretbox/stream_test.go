@@ -0,0 +1,113 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretbox
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+)
+
+func encryptStream(t *testing.T, msg, context, key []byte) []byte {
+	var stream bytes.Buffer
+	w, err := NewWriter(&stream, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create writer: %v", err)
+	}
+	for p := msg; len(p) > 0; {
+		n := 1000
+		if n > len(p) {
+			n = len(p)
+		}
+		if _, err = w.Write(p[:n]); err != nil {
+			t.Fatalf("Failed to write: %v", err)
+		}
+		p = p[n:]
+	}
+	if err = w.Close(); err != nil {
+		t.Fatalf("Failed to close writer: %v", err)
+	}
+	return stream.Bytes()
+}
+
+func decryptStream(stream, context, key []byte) ([]byte, error) {
+	r, err := NewReader(bytes.NewReader(stream), context, key)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func TestStream(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	for _, size := range []int{0, 1, ChunkSize - 1, ChunkSize, ChunkSize + 1, 3 * ChunkSize} {
+		msg := make([]byte, size)
+		for i := range msg {
+			msg[i] = byte(i)
+		}
+		stream := encryptStream(t, msg, context, key)
+		chunks := (size + ChunkSize - 1) / ChunkSize
+		if chunks == 0 {
+			chunks = 1
+		}
+		if n := StreamHeaderSize + size + chunks*HeaderSize; len(stream) != n {
+			t.Fatalf("size %d: got stream of %d bytes - want %d", size, len(stream), n)
+		}
+
+		plaintext, err := decryptStream(stream, context, key)
+		if err != nil {
+			t.Fatalf("size %d: Failed to decrypt: %v", size, err)
+		}
+		if !bytes.Equal(plaintext, msg) {
+			t.Fatalf("size %d: plaintext does not match msg", size)
+		}
+		if _, err = decryptStream(stream, []byte("wrongctx"), key); err == nil {
+			t.Fatalf("size %d: reader accepted wrong context", size)
+		}
+		if _, err = decryptStream(stream[:len(stream)-1], context, key); err == nil {
+			t.Fatalf("size %d: reader accepted truncated stream", size)
+		}
+		if _, err = decryptStream(append(stream, 0), context, key); err == nil {
+			t.Fatalf("size %d: reader accepted trailing data", size)
+		}
+	}
+}
+
+func TestStreamChunks(t *testing.T) {
+	const chunk = ChunkSize + HeaderSize
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	stream := encryptStream(t, make([]byte, 3*ChunkSize+10), context, key)
+
+	// Remove the last chunk - the stream ends at a chunk boundary.
+	truncated := stream[:StreamHeaderSize+3*chunk]
+	if _, err := decryptStream(truncated, context, key); err != errTruncated {
+		t.Fatalf("reader did not detect missing final chunk: %v", err)
+	}
+
+	// Swap the first and second chunk.
+	reordered := append([]byte(nil), stream...)
+	copy(reordered[StreamHeaderSize:], stream[StreamHeaderSize+chunk:StreamHeaderSize+2*chunk])
+	copy(reordered[StreamHeaderSize+chunk:], stream[StreamHeaderSize:StreamHeaderSize+chunk])
+	if _, err := decryptStream(reordered, context, key); err == nil {
+		t.Fatal("reader accepted reordered chunks")
+	}
+
+	// Chunks of another stream with the same key must not be accepted.
+	other := encryptStream(t, make([]byte, 3*ChunkSize+10), context, key)
+	spliced := append([]byte(nil), stream...)
+	copy(spliced[StreamHeaderSize+chunk:], other[StreamHeaderSize+chunk:StreamHeaderSize+2*chunk])
+	if _, err := decryptStream(spliced, context, key); err == nil {
+		t.Fatal("reader accepted chunk of another stream")
+	}
+
+	if _, err := NewReader(bytes.NewReader(nil), context, key); err != errTruncated {
+		t.Fatalf("reader accepted empty stream: %v", err)
+	}
+	if _, err := decryptStream(stream[:StreamHeaderSize], context, key); err != errTruncated {
+		t.Fatalf("reader accepted stream without chunks: %v", err)
+	}
+}
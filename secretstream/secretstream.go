@@ -0,0 +1,272 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package secretstream encrypts and authenticates a sequence of
+// messages with a secret key.
+//
+// A sender creates a PushState and sends its header to the receiver,
+// which creates a matching PullState. Every message is encrypted with
+// ChaCha12 and authenticated with SipHash-128 using a one-time subkey
+// derived from the message counter. Therefore messages can neither be
+// reordered, dropped nor replayed without the receiver noticing.
+//
+// Each message carries a tag. TagPush marks the end of a set of messages,
+// TagRekey rekeys the stream after the message and TagFinal marks the last
+// message of the stream. Furthermore both states rekey the stream after a
+// configurable number of messages. A state can be serialized to resume
+// a stream later on.
+package secretstream
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strconv"
+
+	"github.com/aead/hydrogen/auth"
+	"github.com/aead/hydrogen/internal/chacha20"
+	"github.com/aead/hydrogen/subtle"
+)
+
+const (
+	// TagMessage is the tag of a regular message.
+	TagMessage byte = iota
+	// TagPush marks the end of a set of messages.
+	TagPush
+	// TagRekey forces a rekey of the stream after the message.
+	TagRekey
+	// TagFinal marks the last message of the stream.
+	TagFinal
+)
+
+const (
+	// KeySize is the size of the key in bytes.
+	KeySize = 32
+	// HeaderSize is the size of the stream header in bytes.
+	HeaderSize = 16
+	// Overhead is the difference between the length of a ciphertext
+	// and its plaintext in bytes.
+	Overhead = 1 + auth.TagSize
+
+	stateSize    = 1 + KeySize + 8 + 8 + 8 + 1
+	stateVersion = 1
+)
+
+var (
+	errDecrypt      = errors.New("hydrogen/secretstream: authentication failed")
+	errFinal        = errors.New("hydrogen/secretstream: stream is finalized")
+	errInvalidState = errors.New("hydrogen/secretstream: invalid serialized state")
+)
+
+type state struct {
+	key      [KeySize]byte
+	context  [8]byte
+	counter  uint64
+	interval uint64
+	final    bool
+}
+
+// PushState encrypts the messages of a stream.
+type PushState struct{ state }
+
+// PullState decrypts the messages of a stream.
+type PullState struct{ state }
+
+// NewPushState returns a new PushState and the stream header which must be
+// sent to the receiver. The stream is rekeyed after every rekeyInterval
+// messages - if rekeyInterval is 0 the stream is only rekeyed on request.
+// The reader should return random data or can be nil - than the PRNG of the
+// system will be used. The context must be 8 and the key 32 bytes long,
+// otherwise this function panics.
+func NewPushState(rand io.Reader, rekeyInterval uint64, context, key []byte) (*PushState, []byte, error) {
+	if rand == nil {
+		rand = crand.Reader // use global RNG
+	}
+	header := make([]byte, HeaderSize)
+	if _, err := io.ReadFull(rand, header); err != nil {
+		return nil, nil, err
+	}
+	s := new(PushState)
+	s.init(header, rekeyInterval, context, key)
+	return s, header, nil
+}
+
+// NewPullState returns a new PullState for the stream with the given header.
+// The rekeyInterval must match the one of the sender. The header must be 16,
+// the context 8 and the key 32 bytes long, otherwise this function panics.
+func NewPullState(header []byte, rekeyInterval uint64, context, key []byte) *PullState {
+	if h := len(header); h != HeaderSize {
+		panic("hydrogen/secretstream: invalid header size " + strconv.Itoa(h))
+	}
+	s := new(PullState)
+	s.init(header, rekeyInterval, context, key)
+	return s
+}
+
+// Push encrypts msg with the given tag, authenticates it together with the
+// additional data and appends the result to dst. To reuse msg's storage for
+// the ciphertext, use msg[:0] as dst. It returns a non-nil error if the stream
+// has been finalized.
+func (s *PushState) Push(dst, msg, additionalData []byte, tag byte) ([]byte, error) {
+	if tag > TagFinal {
+		panic("hydrogen/secretstream: invalid tag " + strconv.Itoa(int(tag)))
+	}
+	if s.final {
+		return dst, errFinal
+	}
+	var t [64]byte
+	s.messageKeys(&t)
+	macKey, encKey := t[:16], t[32:]
+
+	// c   = ChaCha12(tag || msg, encKey)
+	// mac = SipHash(len(ad) || ad || c, context, macKey)
+	ret, out := sliceForAppend(dst, len(msg)+Overhead)
+	ciphertext := out[:1+len(msg)]
+	copy(ciphertext[1:], msg) // copy handles overlapping slices
+	ciphertext[0] = tag
+	chacha20.XORKeyStream(ciphertext, ciphertext, zero[:chacha20.NonceSize], encKey)
+	s.mac(out[1+len(msg):], macKey, additionalData, ciphertext)
+
+	s.advance(tag)
+	return ret, nil
+}
+
+// Pull verifies and decrypts the ciphertext together with the additional
+// data and appends the resulting message to dst. It returns the message and
+// its tag. If the ciphertext cannot be authenticated or the stream has been
+// finalized, Pull returns a non-nil error and does not modify the state.
+func (s *PullState) Pull(dst, ciphertext, additionalData []byte) ([]byte, byte, error) {
+	if s.final {
+		return dst, 0, errFinal
+	}
+	if len(ciphertext) < Overhead {
+		return dst, 0, errDecrypt
+	}
+	var t [64]byte
+	s.messageKeys(&t)
+	macKey, encKey := t[:16], t[32:]
+
+	var mac [auth.TagSize]byte
+	c := ciphertext[:len(ciphertext)-auth.TagSize]
+	s.mac(mac[:], macKey, additionalData, c)
+	if !subtle.Equal(mac[:], ciphertext[len(c):]) {
+		return dst, 0, errDecrypt
+	}
+
+	// tag || msg = ChaCha12(c, encKey)
+	ret, out := sliceForAppend(dst, len(c))
+	chacha20.XORKeyStream(out, c, zero[:chacha20.NonceSize], encKey)
+	tag := out[0]
+	if tag > TagFinal {
+		return dst, 0, errDecrypt
+	}
+	copy(out, out[1:])
+	ret = ret[:len(ret)-1]
+
+	s.advance(tag)
+	return ret, tag, nil
+}
+
+// Rekey replaces the key of the stream. If a sender calls Rekey the
+// receiver must call Rekey at the same position of the stream.
+func (s *state) Rekey() {
+	// key = ChaCha12(counter || 1 || {0}, key)
+	var t [64]byte
+	var nonce [16]byte
+	binary.LittleEndian.PutUint64(nonce[:], s.counter)
+	nonce[8] = 1
+	chacha20.Core(&t, nonce[:], s.key[:])
+	copy(s.key[:], t[:KeySize])
+	s.counter = 0
+}
+
+// MarshalBinary returns the serialized state. It contains the
+// current key of the stream and must be kept secret.
+//
+// The message keys depend only on the key and the counter of the state.
+// Therefore a serialized PushState must never be resumed more than once.
+// Pushing different messages from two copies of the same state reuses
+// the keystream and reveals the XOR of the messages.
+func (s *state) MarshalBinary() ([]byte, error) {
+	b := make([]byte, stateSize)
+	b[0] = stateVersion
+	copy(b[1:], s.key[:])
+	copy(b[1+KeySize:], s.context[:])
+	binary.LittleEndian.PutUint64(b[1+KeySize+8:], s.counter)
+	binary.LittleEndian.PutUint64(b[1+KeySize+16:], s.interval)
+	if s.final {
+		b[stateSize-1] = 1
+	}
+	return b, nil
+}
+
+// UnmarshalBinary restores a state serialized by MarshalBinary.
+func (s *state) UnmarshalBinary(b []byte) error {
+	if len(b) != stateSize || b[0] != stateVersion || b[stateSize-1] > 1 {
+		return errInvalidState
+	}
+	copy(s.key[:], b[1:])
+	copy(s.context[:], b[1+KeySize:])
+	s.counter = binary.LittleEndian.Uint64(b[1+KeySize+8:])
+	s.interval = binary.LittleEndian.Uint64(b[1+KeySize+16:])
+	s.final = b[stateSize-1] == 1
+	return nil
+}
+
+var zero [16]byte
+
+func (s *state) init(header []byte, rekeyInterval uint64, context, key []byte) {
+	if k := len(key); k != KeySize {
+		panic("hydrogen/secretstream: invalid key size " + strconv.Itoa(k))
+	}
+	if c := len(context); c != 8 {
+		panic("hydrogen/secretstream: invalid context size " + strconv.Itoa(c))
+	}
+	// key = HChaCha12(header, key)
+	chacha20.HChaCha20(s.key[:], header, key)
+	copy(s.context[:], context)
+	s.interval = rekeyInterval
+}
+
+// messageKeys computes macKey || {0} || encKey = ChaCha12(counter || 0 || {0}, key)
+func (s *state) messageKeys(t *[64]byte) {
+	var nonce [16]byte
+	binary.LittleEndian.PutUint64(nonce[:], s.counter)
+	chacha20.Core(t, nonce[:], s.key[:])
+}
+
+func (s *state) mac(dst, macKey, additionalData, ciphertext []byte) {
+	var length [8]byte
+	binary.LittleEndian.PutUint64(length[:], uint64(len(additionalData)))
+	h := auth.New(s.context[:], macKey)
+	h.Write(length[:])
+	h.Write(additionalData)
+	h.Write(ciphertext)
+	h.Sum(dst[:0])
+}
+
+func (s *state) advance(tag byte) {
+	s.counter++
+	switch {
+	case tag == TagFinal:
+		s.final = true
+	case tag == TagRekey, s.interval > 0 && s.counter >= s.interval:
+		s.Rekey()
+	}
+}
+
+// sliceForAppend takes a slice and a requested number of bytes. It returns a
+// slice with the contents of the given slice followed by that many bytes and a
+// second slice that aliases into it and contains only the extra bytes.
+func sliceForAppend(in []byte, n int) (head, tail []byte) {
+	if total := len(in) + n; cap(in) >= total {
+		head = in[:total]
+	} else {
+		head = make([]byte, total)
+		copy(head, in)
+	}
+	tail = head[len(in):]
+	return
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package secretstream
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestStream(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	ad := []byte("additional data")
+
+	push, header, err := NewPushState(nil, 3, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create push state: %v", err)
+	}
+	pull := NewPullState(header, 3, context, key)
+
+	tags := []byte{TagMessage, TagMessage, TagPush, TagMessage, TagRekey, TagMessage, TagMessage, TagMessage, TagFinal}
+	var ciphertexts [][]byte
+	for i, tag := range tags {
+		msg := bytes.Repeat([]byte{byte(i)}, i*10)
+		c, err := push.Push(nil, msg, ad, tag)
+		if err != nil {
+			t.Fatalf("%d: Push failed: %v", i, err)
+		}
+		if len(c) != len(msg)+Overhead {
+			t.Fatalf("%d: got ciphertext of %d bytes - want %d", i, len(c), len(msg)+Overhead)
+		}
+		ciphertexts = append(ciphertexts, c)
+
+		if i > 0 {
+			if _, _, err = pull.Pull(nil, ciphertexts[i-1], ad); err == nil {
+				t.Fatalf("%d: Pull accepted replayed message", i)
+			}
+		}
+		if _, _, err = pull.Pull(nil, c, []byte("wrong ad")); err == nil {
+			t.Fatalf("%d: Pull accepted wrong additional data", i)
+		}
+		m, tg, err := pull.Pull([]byte("prefix"), c, ad)
+		if err != nil {
+			t.Fatalf("%d: Pull failed: %v", i, err)
+		}
+		if tg != tag || !bytes.Equal(m, append([]byte("prefix"), msg...)) {
+			t.Fatalf("%d: Pull returned wrong message or tag %d", i, tg)
+		}
+	}
+	if _, err = push.Push(nil, nil, nil, TagMessage); err != errFinal {
+		t.Fatalf("Push accepted message after final tag: %v", err)
+	}
+	if _, _, err = pull.Pull(nil, ciphertexts[0], ad); err != errFinal {
+		t.Fatalf("Pull accepted message after final tag: %v", err)
+	}
+}
+
+func TestInPlace(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+	msg := []byte("hello world")
+
+	push, header, err := NewPushState(nil, 0, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create push state: %v", err)
+	}
+	pull := NewPullState(header, 0, context, key)
+
+	buf := make([]byte, len(msg), len(msg)+Overhead)
+	copy(buf, msg)
+	ciphertext, err := push.Push(buf[:0], buf, nil, TagMessage)
+	if err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	plaintext, tag, err := pull.Pull(ciphertext[:0], ciphertext, nil)
+	if err != nil || tag != TagMessage || !bytes.Equal(plaintext, msg) {
+		t.Fatalf("in-place Push / Pull failed: got %q: %v", plaintext, err)
+	}
+}
+
+func TestRekeyAndResume(t *testing.T) {
+	context := []byte("libtests")
+	key := make([]byte, KeySize)
+
+	push, header, err := NewPushState(nil, 0, context, key)
+	if err != nil {
+		t.Fatalf("Failed to create push state: %v", err)
+	}
+	pull := NewPullState(header, 0, context, key)
+
+	c0, _ := push.Push(nil, []byte("first"), nil, TagMessage)
+	push.Rekey()
+	c1, _ := push.Push(nil, []byte("second"), nil, TagMessage)
+
+	if _, _, err = pull.Pull(nil, c0, nil); err != nil {
+		t.Fatalf("Pull failed: %v", err)
+	}
+	if _, _, err = pull.Pull(nil, c1, nil); err == nil {
+		t.Fatal("Pull accepted message encrypted with new key")
+	}
+	pull.Rekey()
+
+	state, err := pull.MarshalBinary()
+	if err != nil {
+		t.Fatalf("Failed to serialize state: %v", err)
+	}
+	resumed := new(PullState)
+	if err = resumed.UnmarshalBinary(state); err != nil {
+		t.Fatalf("Failed to deserialize state: %v", err)
+	}
+	if m, _, err := resumed.Pull(nil, c1, nil); err != nil || string(m) != "second" {
+		t.Fatalf("Resumed state failed to pull message: %v", err)
+	}
+	if err = resumed.UnmarshalBinary(state[1:]); err == nil {
+		t.Fatal("UnmarshalBinary accepted invalid state")
+	}
+}
+
+func benchPush(size int, b *testing.B) {
+	push, _, err := NewPushState(nil, 0, make([]byte, 8), make([]byte, KeySize))
+	if err != nil {
+		b.Fatal(err)
+	}
+	msg := make([]byte, size)
+	buf := make([]byte, 0, size+Overhead)
+
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		push.Push(buf, msg, nil, TagMessage)
+	}
+}
+
+func BenchmarkPush64(b *testing.B)   { benchPush(64, b) }
+func BenchmarkPush1024(b *testing.B) { benchPush(1024, b) }
@@ -6,7 +6,11 @@
 // in cryptographic code. All functions in subtle take constant time.
 package subtle
 
-import csubtle "crypto/subtle"
+import (
+	csubtle "crypto/subtle"
+	"errors"
+	"strconv"
+)
 
 // Equal returns true if and only if the two slices, x
 // and y, have equal contents.
@@ -24,3 +28,63 @@ func Increment(val []byte) {
 		t >>= 8
 	}
 }
+
+var errPadding = errors.New("hydrogen/subtle: invalid padding")
+
+// Pad appends msg and an ISO/IEC 7816-4 padding to dst and returns the
+// updated slice. The padding is a 0x80 byte followed by zero bytes such that
+// the length of the padded msg is the next multiple of blockSize greater than
+// len(msg). Therefore at least one byte is appended. The blockSize must be
+// positive, otherwise this function panics.
+func Pad(dst, msg []byte, blockSize int) []byte {
+	if blockSize <= 0 {
+		panic("hydrogen/subtle: invalid block size " + strconv.Itoa(blockSize))
+	}
+	padLen := blockSize - len(msg)%blockSize
+
+	n := len(dst)
+	if total := n + len(msg) + padLen; cap(dst) >= total {
+		dst = dst[:total]
+	} else {
+		tmp := make([]byte, total)
+		copy(tmp, dst)
+		dst = tmp
+	}
+	copy(dst[n:], msg)
+	pad := dst[n+len(msg):]
+	pad[0] = 0x80
+	for i := 1; i < len(pad); i++ {
+		pad[i] = 0
+	}
+	return dst
+}
+
+// Unpad returns the msg of the padded slice by removing the ISO/IEC 7816-4
+// padding added by Pad with the same blockSize. The returned slice aliases
+// padded. Unpad examines the last blockSize bytes of padded in constant time,
+// such that the length of the padding is not leaked through timing. It
+// returns a non-nil error if padded is not correctly padded. The blockSize
+// must be positive, otherwise this function panics.
+func Unpad(padded []byte, blockSize int) ([]byte, error) {
+	if blockSize <= 0 {
+		panic("hydrogen/subtle: invalid block size " + strconv.Itoa(blockSize))
+	}
+	if len(padded) < blockSize {
+		return nil, errPadding
+	}
+
+	// The barrier is the first 0x80 byte (from the end) after zero bytes only.
+	var acc, padLen, valid uint
+	tail := padded[len(padded)-blockSize:]
+	for i := 0; i < blockSize; i++ {
+		c := uint(tail[blockSize-1-i])
+		isBarrier := (((acc - 1) & (padLen - 1) & ((c ^ 0x80) - 1)) >> 8) & 1
+		acc |= c
+		padLen |= uint(i) & -isBarrier
+		valid |= isBarrier
+	}
+	if valid == 0 {
+		return nil, errPadding
+	}
+	return padded[:len(padded)-1-int(padLen)], nil
+}
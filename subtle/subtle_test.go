@@ -53,6 +53,58 @@ func TestIncrement(t *testing.T) {
 	}
 }
 
+var padTest = []struct {
+	msg       []byte
+	blockSize int
+	padded    []byte
+}{
+	{nil, 1, []byte{0x80}},
+	{nil, 4, []byte{0x80, 0x00, 0x00, 0x00}},
+	{[]byte{0x01}, 1, []byte{0x01, 0x80}},
+	{[]byte{0x01}, 4, []byte{0x01, 0x80, 0x00, 0x00}},
+	{[]byte{0x01, 0x02, 0x03}, 4, []byte{0x01, 0x02, 0x03, 0x80}},
+	{[]byte{0x01, 0x02, 0x03, 0x04}, 4, []byte{0x01, 0x02, 0x03, 0x04, 0x80, 0x00, 0x00, 0x00}},
+	{[]byte{0x80, 0x00}, 3, []byte{0x80, 0x00, 0x80}},
+	{[]byte{0x80, 0x00, 0x80}, 3, []byte{0x80, 0x00, 0x80, 0x80, 0x00, 0x00}},
+}
+
+func TestPad(t *testing.T) {
+	for i, v := range padTest {
+		padded := Pad([]byte("prefix"), v.msg, v.blockSize)
+		if !bytes.Equal(padded[6:], v.padded) || string(padded[:6]) != "prefix" {
+			t.Errorf("%d: got %x expected %x", i, padded[6:], v.padded)
+		}
+		msg, err := Unpad(v.padded, v.blockSize)
+		if err != nil {
+			t.Errorf("%d: Unpad failed: %v", i, err)
+		}
+		if !bytes.Equal(msg, v.msg) {
+			t.Errorf("%d: Unpad: got %x expected %x", i, msg, v.msg)
+		}
+	}
+}
+
+var unpadTest = []struct {
+	padded    []byte
+	blockSize int
+}{
+	{nil, 1},
+	{[]byte{0x80}, 2},
+	{[]byte{0x00}, 1},
+	{[]byte{0x00, 0x00, 0x00, 0x00}, 4},
+	{[]byte{0x01, 0x02, 0x03, 0x04}, 4},
+	{[]byte{0x80, 0x00, 0x00, 0x01}, 4},
+	{[]byte{0x80, 0x00, 0x00, 0x00, 0x00}, 4},
+}
+
+func TestUnpadInvalid(t *testing.T) {
+	for i, v := range unpadTest {
+		if _, err := Unpad(v.padded, v.blockSize); err == nil {
+			t.Errorf("%d: Unpad accepted invalid padding %x", i, v.padded)
+		}
+	}
+}
+
 func benchEqual(size int, b *testing.B) {
 	x, y := make([]byte, size), make([]byte, size)
 	b.SetBytes(int64(size))
@@ -84,3 +136,15 @@ func BenchmarkIncrement_100(b *testing.B)  { benchIncrement(100, b) }
 func BenchmarkIncrement_1K(b *testing.B)   { benchIncrement(1024, b) }
 func BenchmarkIncrement_10K(b *testing.B)  { benchIncrement(10*1024, b) }
 func BenchmarkIncrement_100K(b *testing.B) { benchIncrement(100*1024, b) }
+
+func benchUnpad(size int, b *testing.B) {
+	padded := Pad(nil, make([]byte, size-1), size)
+	b.SetBytes(int64(size))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Unpad(padded, size)
+	}
+}
+
+func BenchmarkUnpad_16(b *testing.B)  { benchUnpad(16, b) }
+func BenchmarkUnpad_256(b *testing.B) { benchUnpad(256, b) }
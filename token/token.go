@@ -0,0 +1,121 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+// Package token generates random strings like API tokens,
+// invitation codes, UUIDs and passphrases.
+//
+// All functions draw their randomness from the hydrogen/random
+// package and select every symbol uniformly at random.
+package token
+
+import (
+	"encoding/hex"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aead/hydrogen/random"
+)
+
+// Alphabet is a set of distinct symbols used to generate random
+// strings. An alphabet must contain at least two symbols.
+type Alphabet string
+
+const (
+	// Base32 is the alphabet of the standard base32 encoding (RFC 4648).
+	Base32 Alphabet = "ABCDEFGHIJKLMNOPQRSTUVWXYZ234567"
+	// Base62 is the alphabet of all digits and upper- and lowercase letters.
+	Base62 Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// Hex is the alphabet of the lowercase hexadecimal encoding.
+	Hex Alphabet = "0123456789abcdef"
+)
+
+// UUIDEntropy is the entropy of a random (version 4) UUID in bits.
+const UUIDEntropy = 122
+
+// Entropy returns the entropy in bits of a random string with
+// the given length over the alphabet a. Like String, this function
+// panics if the alphabet is invalid.
+func (a Alphabet) Entropy(length int) float64 {
+	return float64(length) * math.Log2(float64(len(a.symbols())))
+}
+
+func (a Alphabet) symbols() []rune {
+	symbols := []rune(string(a))
+	if len(symbols) < 2 {
+		panic("hydrogen/token: alphabet contains less than two symbols")
+	}
+	seen := make(map[rune]bool, len(symbols))
+	for _, s := range symbols {
+		if seen[s] {
+			panic("hydrogen/token: alphabet contains duplicate symbol " + strconv.QuoteRune(s))
+		}
+		seen[s] = true
+	}
+	return symbols
+}
+
+// String returns a random string of the given length over the
+// alphabet. This function panics if length is negative or the
+// alphabet is invalid.
+func String(length int, alphabet Alphabet) string {
+	if length < 0 {
+		panic("hydrogen/token: invalid length " + strconv.Itoa(length))
+	}
+	symbols := alphabet.symbols()
+	s := make([]rune, length)
+	for i := range s {
+		s[i] = symbols[random.Uniform(uint32(len(symbols)))]
+	}
+	return string(s)
+}
+
+// UUID is a universally unique identifier as specified in RFC 4122.
+type UUID [16]byte
+
+// NewUUID returns a random (version 4) UUID.
+func NewUUID() UUID {
+	var u UUID
+	random.Bytes(u[:])
+	u[6] = (u[6] & 0x0f) | 0x40 // version 4
+	u[8] = (u[8] & 0x3f) | 0x80 // variant RFC 4122
+	return u
+}
+
+// String returns the canonical text representation of u:
+// xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx
+func (u UUID) String() string {
+	var s [36]byte
+	hex.Encode(s[0:8], u[0:4])
+	s[8] = '-'
+	hex.Encode(s[9:13], u[4:6])
+	s[13] = '-'
+	hex.Encode(s[14:18], u[6:8])
+	s[18] = '-'
+	hex.Encode(s[19:23], u[8:10])
+	s[23] = '-'
+	hex.Encode(s[24:], u[10:])
+	return string(s[:])
+}
+
+// PassphraseEntropy returns the entropy in bits of a passphrase
+// consisting of the given number of words.
+func PassphraseEntropy(words int) float64 {
+	return float64(words) * math.Log2(float64(len(wordlist)))
+}
+
+// Passphrase returns a diceware-like passphrase consisting of the given
+// number of words separated by separator. Every word is chosen uniformly
+// from a list of 1296 words and adds about 10.3 bits of entropy.
+// This function panics if words is negative.
+func Passphrase(words int, separator string) string {
+	if words < 0 {
+		panic("hydrogen/token: invalid number of words " + strconv.Itoa(words))
+	}
+	p := make([]string, words)
+	for i := range p {
+		p[i] = wordlist[random.Uniform(uint32(len(wordlist)))]
+	}
+	return strings.Join(p, separator)
+}
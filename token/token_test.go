@@ -0,0 +1,96 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package token
+
+import (
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestWordlist(t *testing.T) {
+	seen := make(map[string]bool, len(wordlist))
+	for i, w := range wordlist {
+		if w == "" || seen[w] {
+			t.Fatalf("%d: empty or duplicate word %q", i, w)
+		}
+		seen[w] = true
+	}
+}
+
+var stringTests = []struct {
+	alphabet Alphabet
+	length   int
+	entropy  float64
+}{
+	{Hex, 32, 128},
+	{Base32, 26, 130},
+	{Base62, 22, 22 * math.Log2(62)},
+	{Alphabet("01"), 8, 8},
+	{Alphabet("äöü"), 5, 5 * math.Log2(3)},
+}
+
+func TestString(t *testing.T) {
+	for i, v := range stringTests {
+		s := String(v.length, v.alphabet)
+		if n := utf8.RuneCountInString(s); n != v.length {
+			t.Fatalf("%d: got string of length %d - want %d", i, n, v.length)
+		}
+		for _, r := range s {
+			if !strings.ContainsRune(string(v.alphabet), r) {
+				t.Fatalf("%d: symbol %q is not part of the alphabet", i, r)
+			}
+		}
+		if e := v.alphabet.Entropy(v.length); math.Abs(e-v.entropy) > 1e-9 {
+			t.Fatalf("%d: got entropy %f - want %f", i, e, v.entropy)
+		}
+	}
+}
+
+func TestInvalidAlphabet(t *testing.T) {
+	for i, a := range []Alphabet{"", "a", "aab", "abca"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: String accepted invalid alphabet %q", i, a)
+				}
+			}()
+			String(8, a)
+		}()
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%d: Entropy accepted invalid alphabet %q", i, a)
+				}
+			}()
+			a.Entropy(8)
+		}()
+	}
+}
+
+var uuidPattern = regexp.MustCompile("^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$")
+
+func TestUUID(t *testing.T) {
+	for i := 0; i < 64; i++ {
+		if s := NewUUID().String(); !uuidPattern.MatchString(s) {
+			t.Fatalf("%d: invalid UUID %s", i, s)
+		}
+	}
+}
+
+func TestPassphrase(t *testing.T) {
+	p := Passphrase(6, "-")
+	if n := len(strings.Split(p, "-")); n != 6 {
+		t.Fatalf("got passphrase %q with %d words - want 6", p, n)
+	}
+	if e := PassphraseEntropy(6); math.Abs(e-6*math.Log2(1296)) > 1e-9 {
+		t.Fatalf("got entropy %f - want %f", e, 6*math.Log2(1296))
+	}
+	if p = Passphrase(0, " "); p != "" {
+		t.Fatalf("got passphrase %q - want empty string", p)
+	}
+}
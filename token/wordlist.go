@@ -0,0 +1,171 @@
+// Copyright (c) 2017 Andreas Auernhammer. All rights reserved.
+// Use of this source code is governed by a license that can be
+// found in the LICENSE file.
+
+package token
+
+// wordlist contains 6^4 = 1296 short, distinct English words.
+var wordlist = [1296]string{
+	"able", "acid", "acorn", "acre", "actor", "adapt", "adobe", "adult",
+	"affix", "afoot", "agent", "agile", "aging", "agony", "ahead", "aide",
+	"aim", "alarm", "album", "alert", "algae", "alias", "alibi", "alien",
+	"align", "alike", "alive", "alley", "allow", "aloe", "alpha", "altar",
+	"amber", "amend", "ample", "amuse", "angel", "anger", "angle", "angry",
+	"ankle", "anvil", "apart", "apex", "apple", "apron", "arbor", "arena",
+	"argue", "arise", "armor", "army", "aroma", "arrow", "art", "ashen",
+	"aside", "aspen", "atlas", "atom", "attic", "audio", "audit", "aunt",
+	"autumn", "avid", "avoid", "awake", "award", "aware", "awful", "axis",
+	"axle", "bacon", "badge", "bagel", "baker", "balmy", "bamboo", "banjo",
+	"barge", "barn", "basil", "basin", "batch", "bath", "baton", "bay",
+	"beach", "beacon", "beady", "beam", "bean", "beard", "beast", "beech",
+	"beef", "begin", "bell", "belt", "bench", "berry", "bicep", "bike",
+	"birch", "bird", "bison", "blade", "blank", "blast", "blaze", "bleak",
+	"blend", "bless", "blimp", "blink", "bliss", "block", "bloom", "blot",
+	"blues", "bluff", "blunt", "blush", "board", "boast", "bonus", "book",
+	"boost", "booth", "boots", "bored", "bound", "bowl", "boxer", "brain",
+	"brand", "brass", "brave", "bread", "break", "brick", "bride", "brief",
+	"brim", "brine", "brisk", "broad", "broil", "brook", "broom", "brush",
+	"buddy", "buggy", "build", "bulb", "bulk", "bunch", "bunny", "burst",
+	"bush", "busy", "cabin", "cable", "cacao", "cadet", "cage", "cake",
+	"calm", "camel", "camp", "canal", "candy", "canoe", "canon", "canyon",
+	"cape", "cargo", "carol", "carp", "carry", "carve", "case", "cash",
+	"castle", "catch", "cause", "cave", "cedar", "cello", "chain", "chair",
+	"chalk", "champ", "chant", "chaos", "charm", "chart", "chase", "cheek",
+	"cheer", "chef", "chess", "chest", "chick", "chief", "child", "chili",
+	"chime", "chin", "chip", "chirp", "choir", "chop", "chord", "chunk",
+	"cider", "cinch", "circle", "city", "civic", "claim", "clamp", "clap",
+	"clash", "clasp", "class", "claw", "clay", "clean", "clear", "clerk",
+	"click", "cliff", "climb", "cling", "cloak", "clock", "close", "cloth",
+	"cloud", "clove", "clown", "club", "clue", "coach", "coast", "cobra",
+	"cocoa", "coil", "coin", "comet", "comic", "coral", "cord", "cork",
+	"corn", "couch", "cough", "count", "cover", "cozy", "crab", "craft",
+	"crane", "crank", "crash", "crate", "crawl", "crayon", "cream", "creek",
+	"crest", "crew", "crib", "crisp", "crop", "cross", "crowd", "crown",
+	"crumb", "crust", "cube", "cubic", "cuff", "curl", "curry", "curve",
+	"cycle", "daily", "dairy", "daisy", "dance", "dandy", "dare", "dash",
+	"data", "dawn", "deal", "debit", "debut", "decal", "decor", "decoy",
+	"deed", "deep", "deer", "delta", "denim", "dense", "depth", "derby",
+	"desk", "dial", "diary", "dice", "diner", "dingo", "dish", "disk",
+	"ditch", "diver", "dizzy", "dock", "dodge", "doing", "doll", "dolphin",
+	"dome", "donor", "donut", "door", "dose", "dove", "dozen", "draft",
+	"drama", "drank", "drape", "draw", "dream", "dress", "drift", "drill",
+	"drink", "drive", "drone", "drum", "dry", "duck", "duet", "dune",
+	"dusk", "dust", "duty", "dwarf", "eager", "eagle", "early", "earth",
+	"easel", "east", "ebony", "echo", "edge", "eel", "eight", "elbow",
+	"elder", "elite", "elk", "elm", "ember", "emerald", "empty", "enamel",
+	"endow", "enjoy", "enter", "entry", "envoy", "epic", "equal", "era",
+	"erase", "error", "essay", "etch", "even", "event", "evoke", "exact",
+	"exile", "exit", "expo", "extra", "fable", "facet", "fact", "fade",
+	"fairy", "faith", "falcon", "fancy", "fang", "farm", "fast", "fauna",
+	"feast", "feather", "fence", "fern", "ferry", "fetch", "fever", "fiber",
+	"fiddle", "field", "fifth", "fig", "film", "final", "finch", "first",
+	"fish", "five", "fjord", "flag", "flame", "flank", "flash", "flask",
+	"fleet", "flesh", "flick", "fling", "flint", "float", "flock", "flood",
+	"floor", "flora", "flour", "fluid", "flute", "foam", "focal", "focus",
+	"foggy", "folk", "font", "forge", "fork", "fort", "forum", "fossil",
+	"found", "fox", "frame", "fresh", "friar", "frog", "frost", "fruit",
+	"fudge", "fuel", "fungi", "funny", "fur", "fuzzy", "gable", "galaxy",
+	"gale", "gamma", "garden", "garlic", "gauge", "gavel", "gear", "gecko",
+	"gem", "genre", "ghost", "giant", "gift", "ginger", "giraffe", "glad",
+	"glade", "glass", "gleam", "glide", "globe", "gloom", "glory", "glove",
+	"glow", "glue", "gnome", "goat", "gold", "golf", "good", "goose",
+	"gorge", "gourd", "grace", "grade", "grain", "grand", "grant", "grape",
+	"graph", "grasp", "grass", "gravel", "gravy", "great", "green", "grid",
+	"grill", "grin", "grip", "grove", "growl", "guard", "guava", "guess",
+	"guest", "guide", "guild", "guitar", "gulf", "gully", "gummy", "guru",
+	"gust", "habit", "hail", "halo", "hammer", "hand", "happy", "harbor",
+	"hardy", "harp", "harvest", "hatch", "haven", "hawk", "hazel", "heap",
+	"heart", "heath", "hedge", "helix", "hello", "helm", "herb", "herd",
+	"hero", "heron", "hiker", "hill", "hinge", "hippo", "hobby", "hockey",
+	"holly", "home", "honey", "hood", "hook", "hope", "horn", "horse",
+	"host", "hotel", "hound", "hour", "house", "hover", "human", "humid",
+	"humor", "hurry", "husky", "hut", "hyena", "icing", "icon", "idea",
+	"idle", "igloo", "image", "inbox", "index", "inlet", "input", "ion",
+	"iris", "iron", "island", "ivory", "ivy", "jacket", "jade", "jaguar",
+	"jam", "jar", "jazz", "jelly", "jersey", "jewel", "jiffy", "jigsaw",
+	"job", "jockey", "jog", "join", "joke", "jolly", "journal", "joy",
+	"judge", "juice", "jumbo", "jump", "jungle", "junior", "jury", "kayak",
+	"keel", "keen", "kennel", "kettle", "key", "kick", "kidney", "kind",
+	"king", "kiosk", "kite", "kitten", "kiwi", "knack", "knee", "knife",
+	"knight", "knit", "knob", "knot", "koala", "label", "lace", "ladder",
+	"lady", "lagoon", "lake", "lamb", "lamp", "lance", "land", "lane",
+	"lantern", "lapel", "laser", "lasso", "latch", "lava", "lawn", "layer",
+	"leaf", "league", "ledge", "legal", "lemon", "lens", "level", "lever",
+	"lilac", "lily", "limb", "lime", "linen", "lion", "lips", "liquid",
+	"list", "liver", "lizard", "llama", "loaf", "lobby", "lobster", "local",
+	"lodge", "lofty", "logic", "lotus", "loud", "lounge", "loyal", "lucky",
+	"lunar", "lunch", "lyric", "macro", "magic", "magnet", "maize", "major",
+	"mango", "manor", "maple", "marble", "march", "marsh", "mason", "match",
+	"maze", "meadow", "medal", "melon", "memo", "mentor", "menu", "merit",
+	"merry", "mesa", "metal", "meteor", "micro", "mild", "mill", "mimic",
+	"mind", "mint", "minus", "mirror", "mist", "mixer", "moat", "model",
+	"modem", "molar", "mole", "monk", "month", "moose", "mop", "moral",
+	"morse", "moss", "motel", "moth", "motor", "mound", "mount", "mouse",
+	"mouth", "movie", "mud", "muffin", "mule", "mural", "music", "mustard",
+	"myth", "nacho", "nail", "name", "nanny", "napkin", "narrow", "native",
+	"navy", "nectar", "needle", "neon", "nephew", "nerve", "nest", "net",
+	"never", "noble", "node", "noise", "north", "nose", "notch", "note",
+	"novel", "nudge", "nugget", "number", "nurse", "nut", "nylon", "oak",
+	"oasis", "oath", "ocean", "octave", "odor", "offer", "olive", "omega",
+	"onion", "onset", "onyx", "opal", "open", "opera", "optic", "orange",
+	"orbit", "orchid", "order", "organ", "otter", "ounce", "outer", "oval",
+	"oven", "owl", "oxide", "oyster", "ozone", "paddle", "pagoda", "paint",
+	"palace", "palm", "panda", "panel", "panic", "pansy", "paper", "parade",
+	"parcel", "park", "parrot", "party", "pasta", "paste", "patch", "path",
+	"patio", "pause", "peach", "peak", "peanut", "pear", "pebble", "pecan",
+	"pedal", "pelican", "pencil", "penny", "pepper", "perch", "petal", "piano",
+	"pickle", "pie", "pier", "pilot", "pine", "pint", "pipe", "pirate",
+	"pitch", "pixel", "pizza", "place", "plaid", "plain", "plan", "plane",
+	"plank", "plant", "plate", "plaza", "plenty", "plum", "plume", "plush",
+	"pocket", "poem", "poet", "point", "polar", "polka", "pond", "pony",
+	"pool", "poppy", "porch", "port", "pose", "possum", "potato", "pouch",
+	"pound", "powder", "prairie", "prism", "prize", "probe", "proof", "prose",
+	"proud", "prune", "pulse", "puma", "pump", "punch", "pupil", "puppy",
+	"purse", "puzzle", "pylon", "quail", "quake", "quart", "quartz", "queen",
+	"quest", "quick", "quiet", "quill", "quilt", "quota", "quote", "rabbit",
+	"raccoon", "race", "radar", "radio", "raft", "rail", "rain", "raisin",
+	"rake", "rally", "ramp", "ranch", "range", "rapid", "raven", "razor",
+	"reach", "realm", "recipe", "reef", "relay", "relic", "remedy", "rhino",
+	"rhyme", "rib", "ribbon", "rice", "ride", "ridge", "right", "ring",
+	"rinse", "ripple", "river", "road", "robin", "robot", "rocket", "rodeo",
+	"roof", "rookie", "room", "root", "rope", "rose", "rotor", "round",
+	"route", "rover", "royal", "ruby", "rudder", "rug", "rugby", "ruler",
+	"rumble", "rune", "rural", "rust", "saddle", "safari", "saga", "sage",
+	"sail", "salad", "salmon", "salon", "salsa", "salt", "salute", "sample",
+	"sand", "satin", "sauce", "sauna", "scale", "scarf", "scene", "scent",
+	"school", "scoop", "scope", "scout", "scrap", "screen", "script", "scroll",
+	"seal", "season", "seat", "sector", "sedan", "seed", "segment", "senior",
+	"sensor", "sepia", "serum", "settle", "seven", "shadow", "shark", "shed",
+	"shelf", "shell", "shield", "shift", "shine", "ship", "shirt", "shoe",
+	"shore", "shrub", "siege", "sierra", "signal", "silk", "silver", "simple",
+	"siren", "sister", "sketch", "ski", "skill", "skull", "sky", "slate",
+	"sled", "sleeve", "slice", "slope", "sloth", "smile", "smoke", "snack",
+	"snail", "snake", "sneeze", "snow", "soap", "soccer", "socket", "sofa",
+	"solar", "solid", "sonar", "sonic", "sound", "soup", "south", "space",
+	"spade", "spark", "speed", "sphere", "spice", "spider", "spine", "spiral",
+	"spirit", "spoon", "sport", "spray", "spring", "sprout", "spruce", "square",
+	"squid", "stable", "stack", "staff", "stage", "stair", "stamp", "star",
+	"statue", "steam", "steel", "stem", "step", "stereo", "stick", "still",
+	"stone", "stool", "storm", "story", "stove", "straw", "stream", "street",
+	"stripe", "studio", "sugar", "suit", "summit", "sun", "super", "surf",
+	"swamp", "swan", "sweater", "swift", "swing", "sword", "syrup", "table",
+	"tablet", "taco", "tail", "talent", "tango", "tank", "tape", "target",
+	"tavern", "taxi", "teacup", "teal", "teapot", "temple", "tenant", "tennis",
+	"tent", "thaw", "theory", "thorn", "thread", "throne", "thumb", "thunder",
+	"ticket", "tide", "tiger", "timber", "tiny", "toast", "today", "token",
+	"tomato", "tonic", "topaz", "torch", "tower", "toy", "track", "trade",
+	"trail", "train", "tray", "treaty", "tree", "trend", "trial", "tribe",
+	"trick", "trophy", "trout", "truck", "tulip", "tuna", "tundra", "tunnel",
+	"turkey", "turtle", "tutor", "tuxedo", "twig", "twin", "umber", "umbra",
+	"uncle", "union", "unit", "upper", "urban", "usage", "usher", "utmost",
+	"valley", "value", "vapor", "vase", "vault", "velvet", "vendor", "venue",
+	"verb", "verse", "vessel", "vest", "viking", "villa", "vine", "violet",
+	"violin", "visit", "visor", "vista", "vital", "vivid", "vocal", "voice",
+	"volcano", "vortex", "voyage", "wafer", "wagon", "waist", "walnut", "walrus",
+	"wand", "water", "wave", "wax", "weasel", "weaver", "wedge", "whale",
+	"wheat", "wheel", "whisk", "whistle", "widget", "willow", "window", "wing",
+	"winter", "wire", "wisdom", "wizard", "wolf", "wombat", "wonder", "wool",
+	"word", "world", "worm", "wreath", "wrist", "yacht", "yard", "yarn",
+	"yeast", "yellow", "yodel", "yogurt", "yoke", "young", "zebra", "zenith",
+	"zero", "zesty", "zigzag", "zinc", "zipper", "zodiac", "zone", "zoom",
+}